@@ -2,6 +2,7 @@ package v7
 
 import (
 	"context"
+
 	"github.com/go-redis/redis/v7"
 	redislock "github.com/jefferyjob/go-redislock"
 )
@@ -14,8 +15,19 @@ func New(client redis.UniversalClient) redislock.RedisInter {
 	return &RedisAdapter{client: client}
 }
 
+// Eval go-redis v7 的命令不接收 context，执行前检查 ctx 是否已结束；
+// 单机客户端通过 WithContext 传入 ctx，使获取连接时也能响应取消
 func (r *RedisAdapter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) redislock.RedisCmd {
-	cmd := r.client.Eval(script, keys, args...)
+	if err := ctx.Err(); err != nil {
+		return &RedisCmdWrapper{cmd: redis.NewCmdResult(nil, err)}
+	}
+
+	client := r.client
+	if c, ok := client.(*redis.Client); ok {
+		client = c.WithContext(ctx)
+	}
+
+	cmd := client.Eval(script, keys, args...)
 	return &RedisCmdWrapper{cmd: cmd}
 }
 
@@ -9,4 +9,8 @@ require (
 	github.com/jefferyjob/go-redislock v1.7.0-beta
 )
 
-require github.com/google/uuid v1.6.0 // indirect
+require (
+	github.com/alicebob/miniredis/v2 v2.37.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+)
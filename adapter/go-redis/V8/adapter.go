@@ -2,10 +2,18 @@ package v8
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/go-redis/redis/v8"
 	redislock "github.com/jefferyjob/go-redislock"
 )
 
+// errWaitUnsupported Cluster 与 Ring 客户端无法保证 WAIT 与写命令使用同一连接
+var errWaitUnsupported = errors.New("WAIT is not supported by cluster and ring clients")
+
 type RedisAdapter struct {
 	client redis.UniversalClient
 }
@@ -19,6 +27,73 @@ func (r *RedisAdapter) Eval(ctx context.Context, script string, keys []string, a
 	return &RedisCmdWrapper{cmd: cmd}
 }
 
+// FunctionLoad 以 FUNCTION LOAD REPLACE 加载函数库（Redis 7.0+），v8 客户端没有对应方法，通过 Do 执行
+func (r *RedisAdapter) FunctionLoad(ctx context.Context, code string) error {
+	return r.client.Do(ctx, "FUNCTION", "LOAD", "REPLACE", code).Err()
+}
+
+// FCall 调用函数库中的函数（Redis 7.0+），v8 客户端没有对应方法，通过 Do 执行
+func (r *RedisAdapter) FCall(ctx context.Context, function string, keys []string, args ...interface{}) redislock.RedisCmd {
+	cmdArgs := make([]interface{}, 0, 3+len(keys)+len(args))
+	cmdArgs = append(cmdArgs, "FCALL", function, len(keys))
+	for _, key := range keys {
+		cmdArgs = append(cmdArgs, key)
+	}
+	cmdArgs = append(cmdArgs, args...)
+
+	cmd := r.client.Do(ctx, cmdArgs...)
+	return &RedisCmdWrapper{cmd: cmd}
+}
+
+// DoWait 通过 pipeline 在同一连接上依次执行 command 与 WAIT。
+// Cluster 与 Ring 客户端会将无 key 的 WAIT 路由到其他节点，不支持
+func (r *RedisAdapter) DoWait(ctx context.Context, command []interface{}, numReplicas int, timeout time.Duration) (redislock.RedisCmd, int64, error) {
+	if len(command) == 0 {
+		err := fmt.Errorf("invalid command: %v", command)
+		cmd := redis.NewCmd(ctx)
+		cmd.SetErr(err)
+		return &RedisCmdWrapper{cmd: cmd}, 0, err
+	}
+
+	switch r.client.(type) {
+	case *redis.ClusterClient, *redis.Ring:
+		cmd := redis.NewCmd(ctx, command...)
+		cmd.SetErr(errWaitUnsupported)
+		return &RedisCmdWrapper{cmd: cmd}, 0, errWaitUnsupported
+	}
+
+	pipe := r.client.Pipeline()
+	cmd := pipe.Do(ctx, command...)
+	wait := pipe.Do(ctx, "WAIT", numReplicas, timeout.Milliseconds())
+	_, _ = pipe.Exec(ctx) // 错误记录在各命令的结果中
+
+	acked, err := wait.Int64()
+	return &RedisCmdWrapper{cmd: cmd}, acked, err
+}
+
+// Subscribe 订阅频道，收到订阅确认后返回；unsubscribe 关闭订阅连接
+func (r *RedisAdapter) Subscribe(ctx context.Context, channel string) (<-chan string, func(), error) {
+	pubsub := r.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, nil, err
+	}
+
+	messages := make(chan string, 1)
+	go func() {
+		// 订阅关闭后 Channel 随之关闭
+		for msg := range pubsub.Channel() {
+			select {
+			case messages <- msg.Payload:
+			default:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return messages, func() { once.Do(func() { _ = pubsub.Close() }) }, nil
+}
+
 type RedisCmdWrapper struct {
 	cmd *redis.Cmd
 }
@@ -0,0 +1,18 @@
+package v8
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/jefferyjob/go-redislock/internal/adaptertest"
+)
+
+// 适配器一致性测试
+func TestConformance(t *testing.T) {
+	adaptertest.Run(t, func(t *testing.T, addr string) redislock.RedisInter {
+		rdb := redis.NewClient(&redis.Options{Addr: addr})
+		t.Cleanup(func() { _ = rdb.Close() })
+		return New(rdb)
+	})
+}
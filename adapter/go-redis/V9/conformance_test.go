@@ -0,0 +1,18 @@
+package v9
+
+import (
+	"testing"
+
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/jefferyjob/go-redislock/internal/adaptertest"
+	"github.com/redis/go-redis/v9"
+)
+
+// 适配器一致性测试
+func TestConformance(t *testing.T) {
+	adaptertest.Run(t, func(t *testing.T, addr string) redislock.RedisInter {
+		rdb := redis.NewClient(&redis.Options{Addr: addr})
+		t.Cleanup(func() { _ = rdb.Close() })
+		return New(rdb)
+	})
+}
@@ -3,6 +3,7 @@ package v1
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	redislock "github.com/jefferyjob/go-redislock"
 	"github.com/zeromicro/go-zero/core/stores/redis"
@@ -47,9 +48,7 @@ func (w *RdbCmdWrapper) Int64() (int64, error) {
 	case int:
 		return int64(v), nil
 	case string:
-		var i int64
-		_, err := fmt.Sscanf(v, "%d", &i)
-		return i, err
+		return strconv.ParseInt(v, 10, 64)
 	default:
 		return 0, fmt.Errorf("cannot convert result to int: %T", w.cmd)
 	}
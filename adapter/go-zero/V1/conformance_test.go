@@ -0,0 +1,19 @@
+package v1
+
+import (
+	"testing"
+
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/jefferyjob/go-redislock/internal/adaptertest"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+// 适配器一致性测试
+func TestConformance(t *testing.T) {
+	adaptertest.Run(t, func(t *testing.T, addr string) redislock.RedisInter {
+		return New(redis.MustNewRedis(redis.RedisConf{
+			Host: addr,
+			Type: "node",
+		}))
+	})
+}
@@ -0,0 +1,214 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+type RedigoAdapter struct {
+	pool    *redis.Pool
+	scripts sync.Map // 脚本内容 -> *redis.Script
+}
+
+func New(pool *redis.Pool) redislock.RedisInter {
+	return &RedigoAdapter{pool: pool}
+}
+
+// Eval 每次执行从连接池借出一个连接，执行完成后归还
+// 脚本通过 redis.Script 执行：优先 EVALSHA，服务端未缓存脚本时回退为 EVAL
+func (r *RedigoAdapter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) redislock.RedisCmd {
+	if err := ctx.Err(); err != nil {
+		return &RedigoCmdWrapper{err: err}
+	}
+
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return &RedigoCmdWrapper{err: err}
+	}
+	defer conn.Close()
+
+	// keyCount 为负数时，由第一个参数指定 key 的数量
+	keysAndArgs := make([]interface{}, 0, 1+len(keys)+len(args))
+	keysAndArgs = append(keysAndArgs, len(keys))
+	for _, key := range keys {
+		keysAndArgs = append(keysAndArgs, key)
+	}
+	keysAndArgs = append(keysAndArgs, args...)
+
+	reply, err := r.luaScript(script).DoContext(ctx, conn, keysAndArgs...)
+	return &RedigoCmdWrapper{reply: reply, err: err}
+}
+
+// FunctionLoad 以 FUNCTION LOAD REPLACE 加载函数库（Redis 7.0+）
+func (r *RedigoAdapter) FunctionLoad(ctx context.Context, code string) error {
+	_, err := r.do(ctx, "FUNCTION", "LOAD", "REPLACE", code)
+	return err
+}
+
+// FCall 调用函数库中的函数（Redis 7.0+）
+func (r *RedigoAdapter) FCall(ctx context.Context, function string, keys []string, args ...interface{}) redislock.RedisCmd {
+	cmdArgs := make([]interface{}, 0, 2+len(keys)+len(args))
+	cmdArgs = append(cmdArgs, function, len(keys))
+	for _, key := range keys {
+		cmdArgs = append(cmdArgs, key)
+	}
+	cmdArgs = append(cmdArgs, args...)
+
+	reply, err := r.do(ctx, "FCALL", cmdArgs...)
+	return &RedigoCmdWrapper{reply: reply, err: err}
+}
+
+// DoWait 在同一连接上依次执行 command 与 WAIT
+func (r *RedigoAdapter) DoWait(ctx context.Context, command []interface{}, numReplicas int, timeout time.Duration) (redislock.RedisCmd, int64, error) {
+	if len(command) == 0 {
+		err := fmt.Errorf("invalid command: %v", command)
+		return &RedigoCmdWrapper{err: err}, 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return &RedigoCmdWrapper{err: err}, 0, err
+	}
+
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return &RedigoCmdWrapper{err: err}, 0, err
+	}
+	defer conn.Close()
+
+	reply, err := redis.DoContext(conn, ctx, toCommandName(command[0]), command[1:]...)
+	acked, waitErr := redis.Int64(redis.DoContext(conn, ctx, "WAIT", numReplicas, timeout.Milliseconds()))
+	return &RedigoCmdWrapper{reply: reply, err: err}, acked, waitErr
+}
+
+func toCommandName(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// do 从连接池借出一个连接执行命令，执行完成后归还
+func (r *RedigoAdapter) do(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return redis.DoContext(conn, ctx, command, args...)
+}
+
+// luaScript 按脚本内容复用 redis.Script，避免重复计算 SHA1
+func (r *RedigoAdapter) luaScript(script string) *redis.Script {
+	if s, ok := r.scripts.Load(script); ok {
+		return s.(*redis.Script)
+	}
+	s, _ := r.scripts.LoadOrStore(script, redis.NewScript(-1, script))
+	return s.(*redis.Script)
+}
+
+// Subscribe 从连接池借出一个连接专用于订阅，收到订阅确认后返回；
+// unsubscribe 发送 UNSUBSCRIBE，收到取消确认（或连接出错）后归还连接
+func (r *RedigoAdapter) Subscribe(ctx context.Context, channel string) (<-chan string, func(), error) {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	psc := redis.PubSubConn{Conn: conn}
+	if err = psc.Subscribe(channel); err != nil {
+		_ = conn.Close()
+		return nil, nil, err
+	}
+
+	// 等待订阅确认
+	for subscribed := false; !subscribed; {
+		switch v := psc.ReceiveContext(ctx).(type) {
+		case redis.Subscription:
+			subscribed = v.Kind == "subscribe"
+		case error:
+			_ = conn.Close()
+			return nil, nil, v
+		}
+	}
+
+	messages := make(chan string, 1)
+	go func() {
+		defer conn.Close()
+		for {
+			switch v := psc.Receive().(type) {
+			case redis.Message:
+				select {
+				case messages <- string(v.Data):
+				default:
+				}
+			case redis.Subscription:
+				if v.Count == 0 {
+					return
+				}
+			case error:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return messages, func() { once.Do(func() { _ = psc.Unsubscribe() }) }, nil
+}
+
+type RedigoCmdWrapper struct {
+	reply interface{}
+	err   error
+}
+
+// Result 将 redigo 的回复转换为与 go-redis 一致的类型：
+// 整数为 int64，字符串为 string，数组为 []interface{}，空回复返回 redis.ErrNil，服务端错误返回 redis.Error
+func (w *RedigoCmdWrapper) Result() (interface{}, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	if w.reply == nil {
+		return nil, redis.ErrNil
+	}
+	return convert(w.reply), nil
+}
+
+func (w *RedigoCmdWrapper) Int64() (int64, error) {
+	v, err := w.Result()
+	if err != nil {
+		return 0, err
+	}
+
+	switch val := v.(type) {
+	case int64:
+		return val, nil
+	case string:
+		return strconv.ParseInt(val, 10, 64)
+	default:
+		return 0, fmt.Errorf("cannot convert result to int: %T", v)
+	}
+}
+
+// convert 批量字符串（[]byte）转为 string，数组递归转换，数组中的服务端错误作为元素保留
+func convert(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			res[i] = convert(item)
+		}
+		return res
+	default:
+		return v
+	}
+}
@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/jefferyjob/go-redislock/internal/adaptertest"
+)
+
+// 适配器一致性测试
+func TestConformance(t *testing.T) {
+	adaptertest.Run(t, func(t *testing.T, addr string) redislock.RedisInter {
+		pool := &redis.Pool{
+			MaxIdle: 2,
+			DialContext: func(ctx context.Context) (redis.Conn, error) {
+				return redis.DialContext(ctx, "tcp", addr)
+			},
+		}
+		t.Cleanup(func() { _ = pool.Close() })
+		return New(pool)
+	})
+}
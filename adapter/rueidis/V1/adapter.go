@@ -0,0 +1,213 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/redis/rueidis"
+)
+
+type RueidisAdapter struct {
+	client  rueidis.Client
+	scripts sync.Map // 脚本内容 -> *rueidis.Lua
+}
+
+func New(client rueidis.Client) redislock.RedisInter {
+	return &RueidisAdapter{client: client}
+}
+
+// Eval 通过 rueidis.Lua 执行脚本：优先 EVALSHA，服务端未缓存脚本时回退为 EVAL
+func (r *RueidisAdapter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) redislock.RedisCmd {
+	strArgs := make([]string, len(args))
+	for i, arg := range args {
+		strArgs[i] = toString(arg)
+	}
+
+	res := r.luaScript(script).Exec(ctx, r.client, keys, strArgs)
+	return &RueidisCmdWrapper{res: res}
+}
+
+// FunctionLoad 以 FUNCTION LOAD REPLACE 加载函数库（Redis 7.0+）
+func (r *RueidisAdapter) FunctionLoad(ctx context.Context, code string) error {
+	cmd := r.client.B().FunctionLoad().Replace().FunctionCode(code).Build()
+	return r.client.Do(ctx, cmd).Error()
+}
+
+// FCall 调用函数库中的函数（Redis 7.0+）
+func (r *RueidisAdapter) FCall(ctx context.Context, function string, keys []string, args ...interface{}) redislock.RedisCmd {
+	strArgs := make([]string, len(args))
+	for i, arg := range args {
+		strArgs[i] = toString(arg)
+	}
+
+	cmd := r.client.B().Fcall().Function(function).Numkeys(int64(len(keys))).Key(keys...).Arg(strArgs...).Build()
+	return &RueidisCmdWrapper{res: r.client.Do(ctx, cmd)}
+}
+
+// DoWait 通过专用连接（Dedicated）在同一连接上依次执行 command 与 WAIT，集群模式下连接绑定到 command 中 key 所在的节点
+func (r *RueidisAdapter) DoWait(ctx context.Context, command []interface{}, numReplicas int, timeout time.Duration) (redislock.RedisCmd, int64, error) {
+	tokens := make([]string, len(command))
+	for i, arg := range command {
+		tokens[i] = toString(arg)
+	}
+	numKeys := 0
+	if len(tokens) >= 3 {
+		numKeys, _ = strconv.Atoi(tokens[2])
+	}
+	if len(tokens) < 3 || numKeys < 0 || 3+numKeys > len(tokens) {
+		err := fmt.Errorf("invalid command: %v", command)
+		return &RueidisCmdWrapper{err: err}, 0, err
+	}
+
+	cmd := r.client.B().Arbitrary(tokens[:3]...).Keys(tokens[3 : 3+numKeys]...).Args(tokens[3+numKeys:]...).Build()
+	wait := r.client.B().Wait().Numreplicas(int64(numReplicas)).Timeout(timeout.Milliseconds()).Build()
+
+	var results []rueidis.RedisResult
+	err := r.client.Dedicated(func(c rueidis.DedicatedClient) error {
+		results = c.DoMulti(ctx, cmd, wait)
+		return nil
+	})
+	if err == nil && len(results) != 2 {
+		err = fmt.Errorf("unexpected DoWait results: %d", len(results))
+	}
+	if err != nil {
+		return &RueidisCmdWrapper{err: err}, 0, err
+	}
+
+	acked, err := results[1].AsInt64()
+	return &RueidisCmdWrapper{res: results[0]}, acked, err
+}
+
+// Subscribe 通过专用连接（Dedicate）订阅频道，收到订阅确认后返回；unsubscribe 归还专用连接，归还时取消订阅
+func (r *RueidisAdapter) Subscribe(ctx context.Context, channel string) (<-chan string, func(), error) {
+	dc, release := r.client.Dedicate()
+
+	messages := make(chan string, 1)
+	subscribed := make(chan struct{}, 1)
+	closed := dc.SetPubSubHooks(rueidis.PubSubHooks{
+		OnMessage: func(m rueidis.PubSubMessage) {
+			select {
+			case messages <- m.Message:
+			default:
+			}
+		},
+		OnSubscription: func(s rueidis.PubSubSubscription) {
+			if s.Kind == "subscribe" && s.Channel == channel {
+				select {
+				case subscribed <- struct{}{}:
+				default:
+				}
+			}
+		},
+	})
+
+	if err := dc.Do(ctx, dc.B().Subscribe().Channel(channel).Build()).Error(); err != nil {
+		release()
+		return nil, nil, err
+	}
+	select {
+	case <-subscribed:
+	case err := <-closed:
+		release()
+		if err == nil {
+			err = rueidis.ErrClosing
+		}
+		return nil, nil, err
+	case <-ctx.Done():
+		release()
+		return nil, nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return messages, func() { once.Do(release) }, nil
+}
+
+// luaScript 按脚本内容复用 rueidis.Lua，避免重复计算 SHA1
+func (r *RueidisAdapter) luaScript(script string) *rueidis.Lua {
+	if lua, ok := r.scripts.Load(script); ok {
+		return lua.(*rueidis.Lua)
+	}
+	lua, _ := r.scripts.LoadOrStore(script, rueidis.NewLuaScript(script))
+	return lua.(*rueidis.Lua)
+}
+
+type RueidisCmdWrapper struct {
+	res rueidis.RedisResult
+	err error // 命令未执行时的错误
+}
+
+// Result 将 RESP3 回复转换为与 go-redis 一致的类型：
+// 整数为 int64，字符串为 string，数组为 []interface{}，空回复返回 rueidis 的 nil 错误
+func (w *RueidisCmdWrapper) Result() (interface{}, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	v, err := w.res.ToAny()
+	if err != nil {
+		return nil, err
+	}
+	return normalize(v), nil
+}
+
+func (w *RueidisCmdWrapper) Int64() (int64, error) {
+	v, err := w.Result()
+	if err != nil {
+		return 0, err
+	}
+
+	switch val := v.(type) {
+	case int64:
+		return val, nil
+	case string:
+		return strconv.ParseInt(val, 10, 64)
+	default:
+		return 0, fmt.Errorf("cannot convert result to int: %T", v)
+	}
+}
+
+// normalize 处理 RESP3 特有的类型：Lua 的 true 在 RESP3 下为布尔值，按 RESP2 规则转为 1
+func normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case bool:
+		if val {
+			return int64(1)
+		}
+		return nil
+	case []interface{}:
+		for i := range val {
+			val[i] = normalize(val[i])
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// toString 按 go-redis 的规则格式化脚本参数
+func toString(v interface{}) string {
+	switch a := v.(type) {
+	case string:
+		return a
+	case []byte:
+		return string(a)
+	case int:
+		return strconv.Itoa(a)
+	case int64:
+		return strconv.FormatInt(a, 10)
+	case float64:
+		return strconv.FormatFloat(a, 'f', -1, 64)
+	case bool:
+		if a {
+			return "1"
+		}
+		return "0"
+	case time.Duration:
+		return strconv.FormatInt(int64(a), 10)
+	default:
+		return fmt.Sprint(a)
+	}
+}
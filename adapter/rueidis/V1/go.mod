@@ -0,0 +1,16 @@
+module github.com/jefferyjob/go-redislock/adapter/rueidis/V1
+
+go 1.21
+
+replace github.com/jefferyjob/go-redislock => ../../..
+
+require (
+	github.com/alicebob/miniredis/v2 v2.37.0
+	github.com/jefferyjob/go-redislock v1.7.0-beta
+	github.com/redis/rueidis v1.0.19
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+)
@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/jefferyjob/go-redislock/internal/adaptertest"
+	"github.com/valkey-io/valkey-go"
+)
+
+// 适配器一致性测试
+func TestConformance(t *testing.T) {
+	adaptertest.Run(t, func(t *testing.T, addr string) redislock.RedisInter {
+		rdb, err := valkey.NewClient(valkey.ClientOption{
+			InitAddress:       []string{addr},
+			DisableCache:      true,
+			ForceSingleClient: true, // miniredis 会响应 CLUSTER 命令，避免被识别为集群
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(rdb.Close)
+		return New(rdb)
+	})
+}
+
+// dedicatedFailure Dedicated 不执行回调直接返回错误，模拟客户端已关闭等情况
+type dedicatedFailure struct {
+	valkey.Client
+}
+
+func (c dedicatedFailure) Dedicated(func(valkey.DedicatedClient) error) error {
+	return errDedicated
+}
+
+var errDedicated = errors.New("dedicated failed")
+
+// Dedicated 失败时 DoWait 返回其错误，不因结果为空而 panic
+func TestDoWaitDedicatedError(t *testing.T) {
+	mini := miniredis.RunT(t)
+	rdb, err := valkey.NewClient(valkey.ClientOption{
+		InitAddress:       []string{mini.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rdb.Close)
+
+	adapter := New(dedicatedFailure{rdb}).(redislock.RedisWaitInter)
+	cmd, acked, err := adapter.DoWait(context.Background(), []interface{}{"EVAL", "return 1", 0}, 1, time.Second)
+	if !errors.Is(err, errDedicated) || acked != 0 {
+		t.Fatalf("DoWait() = (%d, %v), want dedicated error", acked, err)
+	}
+	if _, err = cmd.Result(); !errors.Is(err, errDedicated) {
+		t.Errorf("Result() = %v, want dedicated error", err)
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/jefferyjob/go-redislock/adapter/valkey-go/V1
+
+go 1.21
+
+replace github.com/jefferyjob/go-redislock => ../../..
+
+require (
+	github.com/alicebob/miniredis/v2 v2.37.0
+	github.com/jefferyjob/go-redislock v1.7.0-beta
+	github.com/valkey-io/valkey-go v1.0.52
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+)
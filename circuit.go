@@ -0,0 +1,272 @@
+package go_redislock
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CircuitState 熔断器状态
+type CircuitState int
+
+const (
+	// CircuitClosed 正常放行请求
+	CircuitClosed CircuitState = iota
+	// CircuitOpen 熔断中，请求直接返回 ErrRedisUnavailable
+	CircuitOpen
+	// CircuitHalfOpen 半开，放行一个探测请求
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// 默认连续传输错误次数阈值
+	defaultFailureThreshold = 5
+	// 默认熔断后进入半开状态的间隔
+	defaultHalfOpenInterval = time.Second
+)
+
+// CircuitBreaker 包装 RedisInter 的熔断器。
+// 连续 threshold 次传输错误（连接被拒绝、重置、超时等）后熔断，熔断期间请求直接返回 ErrRedisUnavailable，不再访问 Redis；
+// 每隔 halfOpenInterval 进入半开状态，放行一个探测请求，成功则恢复，仍为传输错误则继续熔断。
+// 服务端返回的错误（如 NOSCRIPT、READONLY）说明 Redis 可达，不计入传输错误
+type CircuitBreaker struct {
+	redis            RedisInter
+	threshold        int
+	halfOpenInterval time.Duration
+	onStateChange    func(from, to CircuitState)
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int       // 连续传输错误次数
+	openedAt time.Time // 最近一次熔断的时间
+	probing  bool      // 半开状态下探测请求是否正在执行
+}
+
+// CircuitOption 熔断器配置
+type CircuitOption func(cb *CircuitBreaker)
+
+// WithFailureThreshold sets the number of consecutive transport errors that opens the circuit, 5 by default
+// WithFailureThreshold 设置触发熔断的连续传输错误次数，默认 5 次
+func WithFailureThreshold(n int) CircuitOption {
+	return func(cb *CircuitBreaker) {
+		cb.threshold = n
+	}
+}
+
+// WithHalfOpenInterval sets how long the circuit stays open before letting a probe request through, 1s by default
+// WithHalfOpenInterval 设置熔断后进入半开状态、放行探测请求的间隔，默认 1 秒
+func WithHalfOpenInterval(d time.Duration) CircuitOption {
+	return func(cb *CircuitBreaker) {
+		cb.halfOpenInterval = d
+	}
+}
+
+// WithStateChange sets a callback invoked on every state transition, e.g. for logging or metrics
+// WithStateChange 设置状态变化回调，可用于日志、监控。回调在熔断器内部锁中同步执行，不应阻塞或调用熔断器的方法
+func WithStateChange(fn func(from, to CircuitState)) CircuitOption {
+	return func(cb *CircuitBreaker) {
+		cb.onStateChange = fn
+	}
+}
+
+// NewCircuitBreaker wraps redisClient with a circuit breaker.
+// NewCircuitBreaker 创建包装 redisClient 的熔断器，可直接作为 RedisInter 传给 New、NewFair。
+// 被包装的客户端实现了 RedisFunctionInter、RedisWaitInter 时，熔断器同样生效
+func NewCircuitBreaker(redisClient RedisInter, options ...CircuitOption) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		redis:            redisClient,
+		threshold:        defaultFailureThreshold,
+		halfOpenInterval: defaultHalfOpenInterval,
+	}
+	for _, f := range options {
+		f(cb)
+	}
+	return cb
+}
+
+// State 返回熔断器当前状态，熔断时间已到但尚未有请求探测时返回 CircuitHalfOpen
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen && time.Since(cb.openedAt) >= cb.halfOpenInterval {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// Eval 熔断时直接返回 ErrRedisUnavailable，否则转发给被包装的客户端并记录结果
+func (cb *CircuitBreaker) Eval(ctx context.Context, script string, keys []string, args ...interface{}) RedisCmd {
+	if err := cb.allow(); err != nil {
+		return errCmd{err: err}
+	}
+
+	cmd := cb.redis.Eval(ctx, script, keys, args...)
+	_, err := cmd.Result()
+	cb.record(err)
+	return cmd
+}
+
+// FunctionLoad 实现 RedisFunctionInter，被包装的客户端不支持时按服务端不支持处理（回退为 EVAL）
+func (cb *CircuitBreaker) FunctionLoad(ctx context.Context, code string) error {
+	fc, ok := cb.redis.(RedisFunctionInter)
+	if !ok {
+		return errFunctionUnsupported
+	}
+	if err := cb.allow(); err != nil {
+		return err
+	}
+
+	err := fc.FunctionLoad(ctx, code)
+	cb.record(err)
+	return err
+}
+
+// FCall 实现 RedisFunctionInter，被包装的客户端不支持时按服务端不支持处理（回退为 EVAL）
+func (cb *CircuitBreaker) FCall(ctx context.Context, function string, keys []string, args ...interface{}) RedisCmd {
+	fc, ok := cb.redis.(RedisFunctionInter)
+	if !ok {
+		return errCmd{err: errFunctionUnsupported}
+	}
+	if err := cb.allow(); err != nil {
+		return errCmd{err: err}
+	}
+
+	cmd := fc.FCall(ctx, function, keys, args...)
+	_, err := cmd.Result()
+	cb.record(err)
+	return cmd
+}
+
+// DoWait 实现 RedisWaitInter
+func (cb *CircuitBreaker) DoWait(ctx context.Context, command []interface{}, numReplicas int, timeout time.Duration) (RedisCmd, int64, error) {
+	rw, ok := cb.redis.(RedisWaitInter)
+	if !ok {
+		return errCmd{err: errWaitUnsupported}, 0, errWaitUnsupported
+	}
+	if err := cb.allow(); err != nil {
+		return errCmd{err: err}, 0, err
+	}
+
+	cmd, acked, waitErr := rw.DoWait(ctx, command, numReplicas, timeout)
+	_, err := cmd.Result()
+	if err == nil {
+		err = waitErr
+	}
+	cb.record(err)
+	return cmd, acked, waitErr
+}
+
+// Subscribe 实现 RedisPubSubInter，被包装的客户端不支持订阅时返回错误（退化为轮询）
+func (cb *CircuitBreaker) Subscribe(ctx context.Context, channel string) (<-chan string, func(), error) {
+	ps, ok := cb.redis.(RedisPubSubInter)
+	if !ok {
+		return nil, nil, errPubSubUnsupported
+	}
+	if err := cb.allow(); err != nil {
+		return nil, nil, err
+	}
+
+	messages, unsubscribe, err := ps.Subscribe(ctx, channel)
+	cb.record(err)
+	return messages, unsubscribe, err
+}
+
+// allow 判断请求是否放行：熔断中返回 ErrRedisUnavailable，半开状态只放行一个探测请求
+func (cb *CircuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitClosed:
+		return nil
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.halfOpenInterval {
+			return ErrRedisUnavailable
+		}
+		cb.setState(CircuitHalfOpen)
+	}
+
+	if cb.probing {
+		return ErrRedisUnavailable
+	}
+	cb.probing = true
+	return nil
+}
+
+// record 记录请求结果，更新熔断器状态
+func (cb *CircuitBreaker) record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	probe := cb.state == CircuitHalfOpen
+	cb.probing = false
+
+	// 调用方取消或超时无法说明 Redis 是否可达，不改变状态
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	if !isTransportErr(err) {
+		cb.failures = 0
+		if probe || cb.state != CircuitClosed {
+			cb.setState(CircuitClosed)
+		}
+		return
+	}
+
+	cb.failures++
+	if probe || cb.failures >= cb.threshold {
+		cb.openedAt = time.Now()
+		cb.setState(CircuitOpen)
+	}
+}
+
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	if cb.state == state {
+		return
+	}
+	from := cb.state
+	cb.state = state
+	if cb.onStateChange != nil {
+		cb.onStateChange(from, state)
+	}
+}
+
+// errFunctionUnsupported 被包装的客户端未实现 RedisFunctionInter，错误信息与服务端不支持 Functions 时一致
+var errFunctionUnsupported = errors.New("unknown command: redis client does not implement RedisFunctionInter")
+
+// errPubSubUnsupported 被包装的客户端未实现 RedisPubSubInter
+var errPubSubUnsupported = errors.New("redis client does not implement RedisPubSubInter")
+
+// isTransportErr 判断错误是否为传输错误（Redis 不可达）：连接错误与网络超时
+func isTransportErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if isConnErr(err) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return strings.Contains(err.Error(), "i/o timeout")
+}
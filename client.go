@@ -0,0 +1,67 @@
+package go_redislock
+
+// Client 锁客户端，创建一次后复用：保存 Redis 客户端与默认配置（超时、key 前缀、重试、日志、监控等），
+// 由同一 Client 创建的锁共享函数库加载状态、日志、监控与续期调度器：
+// 开启自动续期的锁不再各自启动续期协程，而是由调度器合并为批量续期
+//
+//	client := redislock.NewClient(rdb, redislock.WithTimeout(10*time.Second), redislock.WithKeyPrefix("order:"))
+//	lock := client.NewLock("42", redislock.WithAutoRenew())
+type Client struct {
+	redis     RedisInter
+	options   []Option
+	scheduler *renewScheduler
+	functions *functionState
+}
+
+// NewClient creates a Client whose options apply to every lock it creates
+// NewClient 创建锁客户端，options 作为所创建锁的默认配置
+func NewClient(redisClient RedisInter, options ...Option) *Client {
+	// 调度器的批量续期也使用客户端共享的函数库加载状态
+	base := newRedisLock(redisClient, "", options...)
+	base.functions = &functionState{}
+	return &Client{
+		redis:     redisClient,
+		options:   options,
+		scheduler: newRenewScheduler(base),
+		functions: base.functions,
+	}
+}
+
+// WithKeyPrefix sets a prefix prepended to the lock key
+// WithKeyPrefix 设置锁 key 的前缀，通常作为 Client 的默认配置使用
+func WithKeyPrefix(prefix string) Option {
+	return func(lock *RedisLock) {
+		lock.keyPrefix = prefix
+	}
+}
+
+// NewLock creates a lock with the client defaults, options override the defaults
+// NewLock 使用客户端默认配置创建锁，options 覆盖默认配置
+func (c *Client) NewLock(key string, options ...Option) RedisLockInter {
+	return c.newLock(key, options)
+}
+
+// NewRWLock creates a read-write lock, use RLock / WLock and their counterparts on the returned lock
+// NewRWLock 创建读写锁，通过返回值的 RLock、WLock 等方法加锁
+func (c *Client) NewRWLock(key string, options ...Option) RedisLockInter {
+	return c.newLock(key, options)
+}
+
+// NewFairLock creates a fair lock handle with the client defaults
+// NewFairLock 使用客户端默认配置创建公平锁句柄
+func (c *Client) NewFairLock(key string, options ...Option) RedisFairLockInter {
+	return &RedisFairLock{
+		lock: c.newLock(key, options),
+	}
+}
+
+// newLock 合并默认配置与 options 后创建锁，默认配置在前，后者覆盖前者
+func (c *Client) newLock(key string, options []Option) *RedisLock {
+	merged := make([]Option, 0, len(c.options)+len(options))
+	merged = append(merged, c.options...)
+	merged = append(merged, options...)
+	lock := newRedisLock(c.redis, key, merged...)
+	lock.scheduler = c.scheduler
+	lock.functions = c.functions
+	return lock
+}
@@ -0,0 +1,48 @@
+package go_redislock
+
+import "fmt"
+
+// Lua 脚本返回码
+const (
+	// 操作成功
+	codeOK = 1
+	// 公平锁队列已满
+	codeQueueFull = -1
+	// 锁被其他持有者占用
+	codeLockHeld = -2
+	// 不是公平锁队首
+	codeNotQueueHead = -3
+	// 锁模式不匹配
+	codeWrongMode = -4
+	// 不是锁的持有者
+	codeNotOwner = -5
+	// 锁不存在或已过期
+	codeLockExpired = -6
+)
+
+// codeErr 将脚本返回码转换为错误
+// base 为该操作的通用错误（ErrLockFailed / ErrUnLockFailed / ErrLockRenewFailed），
+// 返回的错误同时满足 errors.Is(err, base) 与 errors.Is(err, 具体原因)
+func codeErr(base error, code int64) error {
+	var reason error
+	switch code {
+	case codeOK:
+		return nil
+	case codeQueueFull:
+		reason = ErrQueueFull
+	case codeLockHeld:
+		reason = ErrLockHeld
+	case codeNotQueueHead:
+		reason = ErrNotQueueHead
+	case codeWrongMode:
+		reason = ErrWrongMode
+	case codeNotOwner:
+		reason = ErrNotOwner
+	case codeLockExpired:
+		reason = ErrLockExpired
+	default:
+		return base
+	}
+
+	return fmt.Errorf("%w: %w", base, reason)
+}
@@ -0,0 +1,150 @@
+package go_redislock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DoOption Do、DoWithFairLock、DoRead、DoWrite 的配置
+type DoOption func(cfg *doConfig)
+
+type doConfig struct {
+	// 自旋加锁的超时时间，0 表示只尝试加锁一次
+	spinTimeout time.Duration
+}
+
+// WithSpin acquires the lock by spinning until timeout instead of trying once
+// WithSpin 设置以自旋方式加锁，在 timeout 内不断尝试，默认只尝试一次
+func WithSpin(timeout time.Duration) DoOption {
+	return func(cfg *doConfig) {
+		cfg.spinTimeout = timeout
+	}
+}
+
+// Do runs fn while holding the standard lock.
+// Do 持有普通锁期间执行 fn：
+//   - 加锁失败时不执行 fn，直接返回加锁错误
+//   - 锁丢失（自动续期判定锁丢失，或未开启自动续期时超过锁超时时间）时取消 fn 的 ctx，
+//     context.Cause 返回 ErrLockLost，Do 的返回值同样包含 ErrLockLost
+//   - 无论 fn 是否返回错误或 panic 都会解锁，fn panic 时解锁后重新 panic，解锁的错误与 fn 的错误合并返回
+func Do(ctx context.Context, lock RedisLockInter, fn func(ctx context.Context) error, options ...DoOption) error {
+	return guard(ctx, asRedisLock(lock), options,
+		lock.Lock,
+		func(ctx context.Context, timeout time.Duration) error { return lock.SpinLock(ctx, timeout) },
+		lock.UnLock,
+		fn,
+	)
+}
+
+// DoWithFairLock runs fn while holding the fair lock, see Do
+// DoWithFairLock 持有公平锁期间执行 fn，行为与 Do 一致
+func DoWithFairLock(ctx context.Context, lock RedisFairLockInter, fn func(ctx context.Context) error, options ...DoOption) error {
+	var l *RedisLock
+	if fair, ok := lock.(*RedisFairLock); ok {
+		l = fair.lock
+	}
+	return guard(ctx, l, options,
+		lock.Lock,
+		func(ctx context.Context, timeout time.Duration) error { return lock.SpinLock(ctx, timeout) },
+		lock.UnLock,
+		fn,
+	)
+}
+
+// DoRead runs fn while holding the read lock, see Do
+// DoRead 持有读锁期间执行 fn，行为与 Do 一致
+func DoRead(ctx context.Context, lock RedisLockInter, fn func(ctx context.Context) error, options ...DoOption) error {
+	return guard(ctx, asRedisLock(lock), options,
+		lock.RLock,
+		func(ctx context.Context, timeout time.Duration) error { return lock.SpinRLock(ctx, timeout) },
+		lock.RUnLock,
+		fn,
+	)
+}
+
+// DoWrite runs fn while holding the write lock, see Do
+// DoWrite 持有写锁期间执行 fn，行为与 Do 一致
+func DoWrite(ctx context.Context, lock RedisLockInter, fn func(ctx context.Context) error, options ...DoOption) error {
+	return guard(ctx, asRedisLock(lock), options,
+		lock.WLock,
+		func(ctx context.Context, timeout time.Duration) error { return lock.SpinWLock(ctx, timeout) },
+		lock.WUnLock,
+		fn,
+	)
+}
+
+// asRedisLock 返回 lock 的具体实现，用于感知锁丢失；其他实现（如 mock）返回 nil
+func asRedisLock(lock RedisLockInter) *RedisLock {
+	l, _ := lock.(*RedisLock)
+	return l
+}
+
+// guard 加锁、执行 fn、解锁。l 为 nil 时无法感知锁丢失，fn 的 ctx 只随调用方的 ctx 取消
+func guard(ctx context.Context, l *RedisLock, options []DoOption,
+	lock func(ctx context.Context) error,
+	spin func(ctx context.Context, timeout time.Duration) error,
+	unlock func(ctx context.Context) error,
+	fn func(ctx context.Context) error,
+) (err error) {
+	var cfg doConfig
+	for _, f := range options {
+		f(&cfg)
+	}
+
+	if cfg.spinTimeout > 0 {
+		err = spin(ctx, cfg.spinTimeout)
+	} else {
+		err = lock(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctxFn, cancel := lostContext(ctx, l)
+	defer cancel()
+
+	defer func() {
+		// 调用方的 ctx 可能已取消或超时，解锁使用独立的短超时 ctx
+		ctxUnlock, cancelUnlock := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+		defer cancelUnlock()
+		unlockErr := unlock(ctxUnlock)
+
+		if r := recover(); r != nil {
+			if unlockErr != nil && l != nil {
+				l.logf("Error: unlock after panic failed, Err: %v \n", unlockErr)
+			}
+			panic(r)
+		}
+
+		if cause := context.Cause(ctxFn); errors.Is(cause, ErrLockLost) {
+			err = errors.Join(err, cause)
+		}
+		err = errors.Join(err, unlockErr)
+	}()
+
+	return fn(ctxFn)
+}
+
+// lostContext 返回锁丢失时取消的 ctx，取消原因包含 ErrLockLost。
+// 开启自动续期时由续期判定锁丢失，否则在锁超时时间到期时取消
+func lostContext(ctx context.Context, l *RedisLock) (context.Context, context.CancelFunc) {
+	if l == nil {
+		return context.WithCancel(ctx)
+	}
+
+	if !l.isAutoRenew {
+		return context.WithTimeoutCause(ctx, l.lockTimeout, fmt.Errorf("%w: %w", ErrLockLost, ErrLockExpired))
+	}
+
+	ctxFn, cancel := context.WithCancelCause(ctx)
+	onLost := func(err error) {
+		cancel(fmt.Errorf("%w: %w", ErrLockLost, err))
+	}
+	l.onLost.Store(&onLost)
+	return ctxFn, func() {
+		l.onLost.CompareAndSwap(&onLost, nil)
+		cancel(nil)
+	}
+}
@@ -0,0 +1,281 @@
+package go_redislock
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	//go:embed lua/electionElect.lua
+	electionElectScript string
+	//go:embed lua/electionLeader.lua
+	electionLeaderScript string
+	//go:embed lua/electionResign.lua
+	electionResignScript string
+)
+
+// LeaderInfo 当前 leader 的信息
+type LeaderInfo struct {
+	// ID leader 标识（锁的 Token）
+	ID string
+	// Metadata leader 的元数据，如服务地址
+	Metadata string
+	// Term leader 的任期，每次选出新 leader 时加 1，可作为 fencing token。
+	// 持有者获得锁后尚未递增任期时为 0
+	Term int64
+}
+
+// Election 基于可重入锁的选主：持有锁的候选者为 leader，锁自动续期直到 Resign 或锁丢失。
+// 每次选出新 leader 时任期（term）单调递增，leader 可将任期作为 fencing token 传给下游，拒绝旧任期的写入
+//
+//	election := redislock.NewElection(rdb, "scheduler", "10.0.0.1:8080")
+//	if err := election.Campaign(ctx); err != nil {
+//		return err
+//	}
+//	defer election.Resign(context.Background())
+//	runAsLeader(ctx, election.Term())
+type Election struct {
+	lock     *RedisLock
+	metadata string
+	// 当前任期，不是 leader 时为 0
+	term atomic.Int64
+}
+
+// NewElection creates a leader election on key, metadata is published with the leader identity
+// NewElection 创建选主，metadata 为成为 leader 后对外公布的元数据。
+// options 为锁的配置，候选者标识可通过 WithToken 指定；锁固定开启自动续期，且续期不随 Campaign 的 ctx 结束（WithRenewUntilUnLock）
+func NewElection(redisClient RedisInter, key string, metadata string, options ...Option) *Election {
+	options = append([]Option{WithAutoRenew(), WithRenewUntilUnLock()}, options...)
+	return &Election{
+		lock:     newRedisLock(redisClient, key, options...),
+		metadata: metadata,
+	}
+}
+
+// NewElection creates a leader election with the client defaults, see NewElection
+// NewElection 使用客户端默认配置创建选主，行为与 NewElection 一致
+func (c *Client) NewElection(key string, metadata string, options ...Option) *Election {
+	options = append([]Option{WithAutoRenew(), WithRenewUntilUnLock()}, options...)
+	return &Election{
+		lock:     c.newLock(key, options),
+		metadata: metadata,
+	}
+}
+
+// ID 返回候选者标识
+func (e *Election) ID() string {
+	return e.lock.token
+}
+
+// Term 返回当前任期，不是 leader（未当选、已退位或锁已丢失）时返回 0
+func (e *Election) Term() int64 {
+	return e.term.Load()
+}
+
+// Campaign blocks until elected or ctx is done
+// Campaign 参与选举，阻塞直到当选或 ctx 结束。已是 leader 时直接返回。
+// 当前 leader 退位时通过订阅通知及时唤醒（需客户端实现 RedisPubSubInter），否则在锁到期时重试
+func (e *Election) Campaign(ctx context.Context) error {
+	if e.Term() > 0 {
+		return nil
+	}
+
+	// 首次加锁失败后才订阅，当选时无需订阅
+	var sub *subscription
+	defer func() {
+		sub.close()
+	}()
+
+	for {
+		err := e.lock.Lock(ctx)
+		if err == nil {
+			return e.elect(ctx)
+		}
+
+		var lockErr *LockError
+		if !errors.As(err, &lockErr) || !errors.Is(err, ErrLockHeld) {
+			return err
+		}
+		// 订阅后立即重试加锁，避免订阅之前发出的退位通知丢失
+		if sub == nil {
+			sub = subscribe(ctx, e.lock.redis, leaderChannel(e.lock.key))
+			continue
+		}
+		wait := lockErr.TTL
+		if wait < 0 {
+			wait = e.lock.lockTimeout
+		}
+		if err = sub.wait(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// elect 获得锁后递增任期成为 leader，失败时释放锁
+func (e *Election) elect(ctx context.Context) error {
+	// 锁丢失时不再是 leader
+	onLost := func(error) {
+		e.term.Store(0)
+	}
+	e.lock.onLost.Store(&onLost)
+
+	term, err := e.lock.eval(ctx, electionElectScript,
+		[]string{e.lock.key},
+		e.lock.token,
+		e.metadata,
+	).Int64()
+	if err == nil && term > 0 {
+		e.term.Store(term)
+		return nil
+	}
+
+	if err != nil {
+		err = exceptionErr(err)
+	} else {
+		err = codeErr(ErrLockFailed, term)
+	}
+	ctxUnlock, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+	if unlockErr := e.lock.UnLock(ctxUnlock); unlockErr != nil {
+		e.lock.logf("Error: election unlock after elect failed, Err: %v \n", unlockErr)
+	}
+	return err
+}
+
+// Resign gives up leadership and wakes up other candidates
+// Resign 退位：释放锁并清除 leader 信息（任期保留），通知观察者与其他候选者。不是 leader 时返回解锁的错误
+func (e *Election) Resign(ctx context.Context) error {
+	e.term.Store(0)
+	if err := e.lock.UnLock(ctx); err != nil {
+		return err
+	}
+
+	// 释放锁之后才通知，被唤醒的候选者可以立即当选
+	res, err := e.lock.eval(ctx, electionResignScript, []string{e.lock.key}, e.lock.token).Int64()
+	if err != nil {
+		return exceptionErr(err)
+	}
+	if res != codeOK && res != codeNotOwner {
+		return codeErr(ErrUnLockFailed, res)
+	}
+	return nil
+}
+
+// Leader returns the current leader, ErrNoLeader when there is none
+// Leader 查询当前 leader，没有 leader 时返回 ErrNoLeader
+func (e *Election) Leader(ctx context.Context) (LeaderInfo, error) {
+	info, _, err := e.leader(ctx)
+	return info, err
+}
+
+// leader 查询当前 leader 与锁的剩余有效期
+func (e *Election) leader(ctx context.Context) (LeaderInfo, time.Duration, error) {
+	res, err := e.lock.eval(ctx, electionLeaderScript, []string{e.lock.key}).Result()
+	if err != nil {
+		return LeaderInfo{}, 0, exceptionErr(err)
+	}
+
+	arr, ok := res.([]interface{})
+	if !ok || len(arr) == 0 {
+		return LeaderInfo{}, 0, errors.Join(fmt.Errorf("unexpected leader result: %v", res), ErrException)
+	}
+	if code, err := toInt64(arr[0]); err != nil {
+		return LeaderInfo{}, 0, exceptionErr(err)
+	} else if code != codeOK {
+		return LeaderInfo{}, 0, ErrNoLeader
+	}
+	if len(arr) < 5 {
+		return LeaderInfo{}, 0, errors.Join(fmt.Errorf("unexpected leader result: %v", res), ErrException)
+	}
+
+	pttl, err := toInt64(arr[1])
+	if err != nil {
+		return LeaderInfo{}, 0, exceptionErr(err)
+	}
+	term, err := toInt64(arr[4])
+	if err != nil {
+		return LeaderInfo{}, 0, exceptionErr(err)
+	}
+	info := LeaderInfo{
+		ID:       toStr(arr[2]),
+		Metadata: toStr(arr[3]),
+		Term:     term,
+	}
+	return info, time.Duration(pttl) * time.Millisecond, nil
+}
+
+// Observe returns a channel receiving the leader on every change, closed when ctx is done
+// Observe 返回 leader 变化的通知：先发送当前 leader，之后每次 leader 或任期变化时发送新的 leader，
+// 没有 leader 时发送零值 LeaderInfo。ctx 结束时关闭 channel。
+// 当选、退位通过订阅通知及时发现（需客户端实现 RedisPubSubInter）；leader 崩溃导致锁过期时在锁到期后发现
+func (e *Election) Observe(ctx context.Context) <-chan LeaderInfo {
+	ch := make(chan LeaderInfo, 1)
+	sub := subscribe(ctx, e.lock.redis, leaderChannel(e.lock.key))
+
+	go func() {
+		defer close(ch)
+		defer sub.close()
+
+		var (
+			last LeaderInfo
+			sent bool
+		)
+		for {
+			info, ttl, err := e.leader(ctx)
+			wait := e.lock.lockTimeout
+			switch {
+			case err == nil:
+				// 锁到期时 leader 可能已崩溃，届时重新查询
+				if ttl >= 0 {
+					wait = min(wait, ttl)
+				}
+			case errors.Is(err, ErrNoLeader):
+				info = LeaderInfo{}
+			default:
+				if ctx.Err() != nil {
+					return
+				}
+				e.lock.logf("Error: election observe failed, Err: %v \n", err)
+				wait = failoverRetryInterval
+			}
+
+			if err == nil || errors.Is(err, ErrNoLeader) {
+				if !sent || info != last {
+					select {
+					case ch <- info:
+					case <-ctx.Done():
+						return
+					}
+					last, sent = info, true
+				}
+			}
+
+			if sub.wait(ctx, wait) != nil {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// leaderChannel leader 变化的通知频道，与 leader 信息 key 同名
+func leaderChannel(key string) string {
+	return "{" + key + "}:leader"
+}
+
+// toStr 将脚本返回的字符串（不同客户端为 string 或 []byte）转换为 string
+func toStr(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	default:
+		return ""
+	}
+}
@@ -2,12 +2,10 @@ package fair
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	redislock "github.com/jefferyjob/go-redislock"
 )
 
@@ -19,13 +17,6 @@ type Ticket struct {
 func (t *Ticket) buy(ctx context.Context) {
 	lockKey := "fair:lock"
 
-	lock := redislock.New(
-		t.rdb,
-		lockKey,
-		redislock.WithTimeout(30*time.Second), // 锁 TTL
-		redislock.WithRequestTimeout(10*time.Second), // 10s无法获取锁则放弃
-	)
-
 	var wg sync.WaitGroup
 	userCount := 50
 	wg.Add(userCount)
@@ -33,22 +24,29 @@ func (t *Ticket) buy(ctx context.Context) {
 	for i := 0; i < userCount; i++ {
 		go func(userId int) {
 			defer wg.Done()
-			requestId := fmt.Sprintf("user:%d:%s", userId, uuid.New().String())
+
+			// 每位用户一个公平锁句柄，requestId 由句柄自动生成
+			lock := redislock.NewFair(
+				t.rdb,
+				lockKey,
+				redislock.WithTimeout(30*time.Second), // 锁 TTL
+				redislock.WithRequestTimeout(10*time.Second), // 10s无法获取锁则放弃
+			)
 
 			// 自旋公平锁 —— 在 N 秒内一直尝试
-			if err := lock.SpinFairLock(ctx, requestId, 10*time.Second); err != nil {
-				log.Printf("[%s] 排队超时，未抢到锁: %v", requestId, err)
+			if err := lock.SpinLock(ctx, 10*time.Second); err != nil {
+				log.Printf("[user:%d] 排队超时，未抢到锁: %v", userId, err)
 				return
 			}
 
 			// 下面开始处于临界区，只有队首线程能执行
-			defer lock.FairUnLock(ctx, requestId)
+			defer lock.UnLock(ctx)
 
 			// 检查剩余票数
 			// 扣减库存 / 为该用户锁定票资源
 
 			// 抢票成功
-			log.Printf("[%s] 抢票成功！", requestId)
+			log.Printf("[user:%d][%s] 抢票成功！", userId, lock.RequestId())
 		}(i + 1)
 	}
 
@@ -0,0 +1,76 @@
+package go_redislock
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// failoverPrefixes 故障切换、集群迁移期间服务端返回的错误前缀
+var failoverPrefixes = []string{
+	"READONLY",    // 主从切换后连接仍指向已降级为副本的旧主节点
+	"MOVED",       // 集群槽位已迁移
+	"ASK",         // 集群槽位迁移中
+	"TRYAGAIN",    // 集群槽位迁移中，多 key 操作暂不可用
+	"CLUSTERDOWN", // 集群不可用
+	"LOADING",     // 节点正在加载数据
+	"MASTERDOWN",  // 副本与主节点断开
+}
+
+// isFailoverErr 判断错误是否由故障切换引起（而非锁本身的状态），
+// 包括服务端的 READONLY、MOVED/ASK、LOADING 等错误，以及连接被重置、拒绝、关闭等网络错误
+func isFailoverErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrFailover) || isConnErr(err) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, prefix := range failoverPrefixes {
+		if strings.HasPrefix(msg, prefix+" ") || msg == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// isConnErr 判断错误是否为连接被重置、拒绝、关闭等连接错误
+func isConnErr(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed) {
+		return true
+	}
+
+	// 部分客户端只保留错误信息
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset by peer") ||
+		strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "broken pipe")
+}
+
+// exceptionErr 包装 Redis 客户端返回的错误：
+// 所有错误均满足 errors.Is(err, ErrException)，故障切换引起的错误同时满足 errors.Is(err, ErrFailover)
+func exceptionErr(err error) error {
+	if isFailoverErr(err) {
+		return errors.Join(err, ErrFailover, ErrException)
+	}
+	return errors.Join(err, ErrException)
+}
+
+// WithFailoverGrace sets how long auto-renew keeps retrying through failover errors before declaring the lock lost.
+// WithFailoverGrace 设置自动续期遇到故障切换错误时的最长重试时间，从最后一次续期成功开始计算，默认为当前租期（锁超时时间，续期成功后为续期 TTL）
+func WithFailoverGrace(d time.Duration) Option {
+	return func(lock *RedisLock) {
+		lock.failoverGrace = d
+	}
+}
@@ -0,0 +1,237 @@
+package go_redislock
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// functionLibraryPrefix 函数库名称前缀
+const functionLibraryPrefix = "redislock"
+
+var (
+	// functionVersion 函数库版本，由全部脚本内容计算得出，脚本变化时版本随之变化
+	functionVersion string
+	// functionLibrary 函数库名称，包含版本号，不同版本的函数库可在同一服务端共存
+	functionLibrary string
+	// functionLibraryCode FUNCTION LOAD 使用的函数库代码
+	functionLibraryCode string
+	// scriptFunctions 脚本内容到函数名称的映射
+	scriptFunctions map[string]string
+	// versionFunction 返回函数库版本的函数名称
+	versionFunction string
+)
+
+func init() {
+	scripts := Scripts()
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sum := sha1.New()
+	for _, name := range names {
+		sum.Write([]byte(name))
+		sum.Write([]byte(scripts[name]))
+	}
+	functionVersion = hex.EncodeToString(sum.Sum(nil))[:12]
+	functionLibrary = functionLibraryPrefix + "_" + functionVersion
+	versionFunction = functionLibrary + "_version"
+
+	// 每个脚本包装为一个函数，脚本中的 KEYS、ARGV 由函数参数提供
+	var code strings.Builder
+	fmt.Fprintf(&code, "#!lua name=%s\n", functionLibrary)
+	scriptFunctions = make(map[string]string, len(scripts))
+	for _, name := range names {
+		function := functionLibrary + "_" + name
+		scriptFunctions[scripts[name]] = function
+		fmt.Fprintf(&code, "\nredis.register_function('%s', function(KEYS, ARGV)\n%s\nend)\n", function, scripts[name])
+	}
+	fmt.Fprintf(&code, "\nredis.register_function{function_name='%s', callback=function() return '%s' end, flags={'no-writes'}}\n",
+		versionFunction, functionVersion)
+	functionLibraryCode = code.String()
+}
+
+// FunctionLibrary returns the name and code of the Redis Functions library holding all embedded scripts.
+// The library is loaded automatically when the client implements RedisFunctionInter,
+// it can also be loaded ahead of time with FUNCTION LOAD REPLACE.
+//
+// FunctionLibrary 返回包含全部内置脚本的 Redis Functions 函数库名称与代码。
+// 客户端实现 RedisFunctionInter 时会自动加载，也可以通过 FUNCTION LOAD REPLACE 提前加载。
+// 函数库名称包含版本号（redislock_<version>），不再使用的旧版本可通过 FUNCTION DELETE 删除。
+func FunctionLibrary() (name string, code string) {
+	return functionLibrary, functionLibraryCode
+}
+
+// WithFunctions sets whether to execute scripts via Redis Functions (FCALL) when the client supports it, enabled by default
+// WithFunctions 设置客户端支持时是否通过 Redis Functions（FCALL）执行脚本，默认开启
+func WithFunctions(enabled bool) Option {
+	return func(lock *RedisLock) {
+		lock.disableFunctions = !enabled
+	}
+}
+
+// functionState 函数库加载状态，由同一 Client 创建的锁共享；单独创建的锁按 Redis 客户端共享（见 sharedFunctionState）
+type functionState struct {
+	mu          sync.Mutex
+	loaded      bool          // 函数库已加载
+	unsupported bool          // 服务端不支持 Functions（Redis 7 以下）或无权限，始终使用 EVAL
+	loading     chan struct{} // 正在加载时非空，加载结束时关闭
+}
+
+// sharedFunctions RedisInter -> *functionState，单独创建（New）的锁使用
+var sharedFunctions sync.Map
+
+// sharedFunctionState 返回 Redis 客户端共享的函数库加载状态，避免每个短生命周期的锁都检查一次函数库版本。
+// Redis 客户端通常在进程内长期复用，状态随客户端数量增长；客户端不可比较时返回 nil，始终使用 EVAL
+func sharedFunctionState(rdb RedisInter) *functionState {
+	if !reflect.TypeOf(rdb).Comparable() {
+		return nil
+	}
+	state, _ := sharedFunctions.LoadOrStore(rdb, &functionState{})
+	return state.(*functionState)
+}
+
+// ensure 确保函数库已加载，返回 false 表示本次应使用 EVAL 执行
+func (s *functionState) ensure(ctx context.Context, rdb RedisFunctionInter) bool {
+	return s.load(ctx, rdb, true)
+}
+
+// reload 函数库丢失（如服务端重启、故障切换）后重新加载
+func (s *functionState) reload(ctx context.Context, rdb RedisFunctionInter) bool {
+	return s.load(ctx, rdb, false)
+}
+
+// load 加载函数库。同一时刻只有一个调用访问服务端，其他调用等待其结果；
+// 访问服务端时不持有 mu，已加载的调用不会被阻塞
+func (s *functionState) load(ctx context.Context, rdb RedisFunctionInter, reuse bool) bool {
+	s.mu.Lock()
+	if s.unsupported {
+		s.mu.Unlock()
+		return false
+	}
+	if reuse && s.loaded {
+		s.mu.Unlock()
+		return true
+	}
+	if loading := s.loading; loading != nil {
+		s.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return false
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.loaded
+	}
+	loading := make(chan struct{})
+	s.loading = loading
+	s.mu.Unlock()
+
+	loaded, unsupported := loadLibrary(ctx, rdb, reuse)
+
+	s.mu.Lock()
+	s.loaded, s.unsupported, s.loading = loaded, unsupported, nil
+	s.mu.Unlock()
+	close(loading)
+	return loaded
+}
+
+// loadLibrary 加载函数库，check 为 true 时先检查服务端是否已有当前版本的函数库
+func loadLibrary(ctx context.Context, rdb RedisFunctionInter, check bool) (loaded bool, unsupported bool) {
+	if check {
+		version, err := rdb.FCall(ctx, versionFunction, nil).Result()
+		if err == nil && version == functionVersion {
+			return true, false
+		}
+		if isFunctionUnsupported(err) {
+			return false, true
+		}
+	}
+
+	if err := rdb.FunctionLoad(ctx, functionLibraryCode); err != nil {
+		// 其他错误（如网络错误）本次回退为 EVAL，下次调用时重试加载
+		return false, isFunctionUnsupported(err)
+	}
+	return true, false
+}
+
+// scriptRunner 执行脚本的两种方式：EVAL 与 FCALL
+type scriptRunner struct {
+	eval  func() RedisCmd
+	fcall func(function string) RedisCmd
+}
+
+// eval 执行内置脚本：客户端支持 Redis Functions 时使用 FCALL，否则使用 EVAL
+func (l *RedisLock) eval(ctx context.Context, script string, keys []string, args ...interface{}) RedisCmd {
+	return l.run(ctx, script, scriptRunner{
+		eval: func() RedisCmd {
+			return l.redis.Eval(ctx, script, keys, args...)
+		},
+		fcall: func(function string) RedisCmd {
+			return l.redis.(RedisFunctionInter).FCall(ctx, function, keys, args...)
+		},
+	})
+}
+
+// run 执行脚本并回调监控
+func (l *RedisLock) run(ctx context.Context, script string, runner scriptRunner) RedisCmd {
+	start := time.Now()
+	cmd := l.dispatch(ctx, script, runner)
+	l.observe(script, start, cmd)
+	return cmd
+}
+
+// dispatch 选择脚本的执行方式，函数库不可用时回退为 EVAL
+func (l *RedisLock) dispatch(ctx context.Context, script string, runner scriptRunner) RedisCmd {
+	function, ok := scriptFunctions[script]
+	fc, supported := l.redis.(RedisFunctionInter)
+	if !ok || !supported || l.disableFunctions {
+		return runner.eval()
+	}
+
+	state := l.functions
+	if state == nil {
+		state = sharedFunctionState(l.redis)
+	}
+	if state == nil || !state.ensure(ctx, fc) {
+		return runner.eval()
+	}
+
+	cmd := runner.fcall(function)
+	if _, err := cmd.Result(); !isFunctionNotFound(err) {
+		return cmd
+	}
+
+	// 函数库丢失时重新加载后重试；集群中 FUNCTION LOAD 只发送到单个节点，
+	// 其他节点仍找不到函数时回退为 EVAL
+	if state.reload(ctx, fc) {
+		cmd = runner.fcall(function)
+		if _, err := cmd.Result(); !isFunctionNotFound(err) {
+			return cmd
+		}
+	}
+	return runner.eval()
+}
+
+// isFunctionUnsupported 服务端不支持 FUNCTION/FCALL 命令，或当前用户无权执行
+func isFunctionUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unknown command") || strings.HasPrefix(msg, "noperm")
+}
+
+// isFunctionNotFound 函数不存在（函数库未加载或已丢失）
+func isFunctionNotFound(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "function not found")
+}
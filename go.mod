@@ -9,6 +9,9 @@ retract (
 )
 
 require (
+	github.com/alicebob/miniredis/v2 v2.37.0
 	github.com/golang/mock v1.6.0
 	github.com/google/uuid v1.6.0
 )
+
+require github.com/yuin/gopher-lua v1.1.1 // indirect
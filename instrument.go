@@ -0,0 +1,78 @@
+package go_redislock
+
+import (
+	"log"
+	"time"
+)
+
+// Logger 日志接口，*log.Logger 满足该接口
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// ScriptEvent 一次锁脚本执行的监控数据
+type ScriptEvent struct {
+	// Script 脚本名称，与 Scripts 返回的 key 一致
+	Script string
+	// Key 锁的 key
+	Key string
+	// Err Redis 客户端返回的错误，脚本返回的失败码（如锁已被持有）不属于错误
+	Err error
+	// Elapsed 脚本执行耗时，包括 WAIT 与函数库加载
+	Elapsed time.Duration
+}
+
+// Metrics 监控接口，每次执行锁脚本后同步回调，实现不应阻塞
+type Metrics interface {
+	ObserveScript(event ScriptEvent)
+}
+
+// WithLogger sets the logger used for warnings such as renewal failures, the standard log package by default
+// WithLogger 设置日志，用于输出续期失败、回滚失败等告警，默认使用标准库 log
+func WithLogger(logger Logger) Option {
+	return func(lock *RedisLock) {
+		lock.logger = logger
+	}
+}
+
+// WithMetrics sets a hook observing every script executed by the lock
+// WithMetrics 设置监控回调，锁的每次脚本执行都会回调
+func WithMetrics(metrics Metrics) Option {
+	return func(lock *RedisLock) {
+		lock.metrics = metrics
+	}
+}
+
+// scriptNames 脚本内容到脚本名称的映射
+var scriptNames map[string]string
+
+func init() {
+	scripts := Scripts()
+	scriptNames = make(map[string]string, len(scripts))
+	for name, script := range scripts {
+		scriptNames[script] = name
+	}
+}
+
+// logf 输出日志
+func (l *RedisLock) logf(format string, v ...interface{}) {
+	if l.logger == nil {
+		log.Printf(format, v...)
+		return
+	}
+	l.logger.Printf(format, v...)
+}
+
+// observe 脚本执行完成后回调监控
+func (l *RedisLock) observe(script string, start time.Time, cmd RedisCmd) {
+	if l.metrics == nil {
+		return
+	}
+	_, err := cmd.Result()
+	l.metrics.ObserveScript(ScriptEvent{
+		Script:  scriptNames[script],
+		Key:     l.key,
+		Err:     err,
+		Elapsed: time.Since(start),
+	})
+}
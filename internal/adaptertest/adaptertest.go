@@ -0,0 +1,658 @@
+// Package adaptertest provides a conformance test suite shared by all RedisInter adapters.
+//
+// adaptertest 提供所有 Redis 客户端适配器共用的一致性测试。
+// 测试默认在本地的 miniredis 上执行，无需启动真实 Redis；设置 REDISLOCK_TEST_ADDR 后在指定的服务上执行。覆盖：
+// Eval 返回值类型（整数、空回复、字符串、数组）、错误传递、上下文取消、Redis Functions、WAIT，以及各类锁的端到端流程。
+//
+// adaptertest 是根模块的内部包，只有本仓库 adapter 目录下的适配器模块可以导入，适配器模块无需额外依赖。
+//
+// 在适配器模块中使用：
+//
+//	func TestConformance(t *testing.T) {
+//		adaptertest.Run(t, func(t *testing.T, addr string) redislock.RedisInter {
+//			return New(redis.NewClient(&redis.Options{Addr: addr}))
+//		})
+//	}
+package adaptertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// Factory 使用给定的 Redis 地址（host:port）创建待测适配器
+type Factory func(t *testing.T, addr string) redislock.RedisInter
+
+// AddrEnv 指定外部 Redis 地址的环境变量。
+// 设置后一致性测试将在该服务上执行（用于验证 Redis、Valkey、KeyDB 等服务端的兼容性），
+// 否则在本地启动的 miniredis 上执行
+const AddrEnv = "REDISLOCK_TEST_ADDR"
+
+// server 测试使用的 Redis 服务
+type server struct {
+	mini   *miniredis.Miniredis // 为 nil 时表示外部服务
+	addr   string
+	prefix string // 外部服务上的 key 前缀，避免与其他数据冲突
+	now    time.Time
+}
+
+func newServer(t *testing.T) *server {
+	if addr := os.Getenv(AddrEnv); addr != "" {
+		return &server{addr: addr, prefix: fmt.Sprintf("adaptertest:%d:", time.Now().UnixNano())}
+	}
+
+	s := &server{mini: miniredis.RunT(t), now: time.Now()}
+	s.addr = s.mini.Addr()
+	s.mini.SetTime(s.now)
+	return s
+}
+
+// key 返回测试使用的 key
+func (s *server) key(name string) string {
+	return s.prefix + name
+}
+
+// advance 推进服务端时间：TIME 命令的返回值与 key 的剩余有效期同步变化。
+// 外部服务无法控制时间，直接等待
+func (s *server) advance(d time.Duration) {
+	if s.mini == nil {
+		time.Sleep(d)
+		return
+	}
+	s.now = s.now.Add(d)
+	s.mini.SetTime(s.now)
+	s.mini.FastForward(d)
+}
+
+// Run 对 factory 创建的适配器执行全部一致性测试
+func Run(t *testing.T, factory Factory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, rdb redislock.RedisInter, s *server)
+	}{
+		{name: "ServerCommands", run: testServerCommands},
+		{name: "EvalResult", run: testEvalResult},
+		{name: "EvalError", run: testEvalError},
+		{name: "ContextCanceled", run: testContextCanceled},
+		{name: "ReentrantLock", run: testReentrantLock},
+		{name: "FairLock", run: testFairLock},
+		{name: "PriorityFairLock", run: testPriorityFairLock},
+		{name: "ReadWriteLock", run: testReadWriteLock},
+		{name: "MultiLockScripts", run: testMultiLockScripts},
+		{name: "BatchRenew", run: testBatchRenew},
+		{name: "Functions", run: testFunctions},
+		{name: "MinReplicas", run: testMinReplicas},
+		{name: "Subscribe", run: testSubscribe},
+		{name: "Singleflight", run: testSingleflight},
+		{name: "Election", run: testElection},
+		{name: "CountDownLatch", run: testCountDownLatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t)
+			tt.run(t, factory(t, s.addr), s)
+		})
+	}
+}
+
+// serverCommandsScript 在脚本中执行内置脚本依赖的命令，返回各命令的结果
+const serverCommandsScript = `
+local now = redis.call('TIME')
+redis.call('SET', KEYS[1], 'v', 'NX', 'PX', 10000)
+local set_nx = redis.call('SET', KEYS[1], 'v', 'NX', 'PX', 10000)
+redis.call('PEXPIRE', KEYS[1], 5000)
+local pttl = redis.call('PTTL', KEYS[1])
+local incr = redis.call('HINCRBY', KEYS[2], 'f', 2)
+local decr = redis.call('HINCRBY', KEYS[2], 'f', -1)
+redis.call('ZADD', KEYS[3], 'NX', 200, 'b')
+redis.call('ZADD', KEYS[3], 'NX', 100, 'a')
+redis.call('ZADD', KEYS[3], 'NX', 50, 'a')
+local rank = redis.call('ZRANK', KEYS[3], 'b')
+local score = redis.call('ZSCORE', KEYS[3], 'a')
+local range = redis.call('ZRANGEBYSCORE', KEYS[3], 0, 150)
+local removed = redis.call('ZREMRANGEBYSCORE', KEYS[3], 0, 150)
+local missing = redis.call('ZRANK', KEYS[3], 'a')
+return {
+    #now, tonumber(now[1]) > 0 and 1 or 0, tonumber(now[2]) < 1000000 and 1 or 0,
+    set_nx == false and 1 or 0, pttl > 4000 and pttl <= 5000 and 1 or 0,
+    incr, decr,
+    rank, score, #range, range[1], removed, redis.call('ZCARD', KEYS[3]), missing == false and 1 or 0
+}
+`
+
+// 内置脚本依赖的命令语义（TIME、SET NX PX、PEXPIRE、PTTL、HINCRBY、ZSET）在各服务端上需保持一致
+func testServerCommands(t *testing.T, rdb redislock.RedisInter, s *server) {
+	keys := []string{s.key("{cmd}:string"), s.key("{cmd}:hash"), s.key("{cmd}:zset")}
+	got, err := rdb.Eval(context.Background(), serverCommandsScript, keys).Result()
+	if err != nil {
+		t.Fatalf("Result() returned unexpected error: %v", err)
+	}
+
+	want := []interface{}{
+		int64(2), int64(1), int64(1), // TIME 返回秒与微秒
+		int64(1), int64(1), // SET NX 在 key 存在时返回空回复，PEXPIRE 生效
+		int64(2), int64(1), // HINCRBY
+		int64(1), "100", int64(1), "a", int64(1), int64(1), int64(1), // ZADD NX 不更新已有成员
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Result() = %#v, want %#v", got, want)
+	}
+}
+
+// Eval 返回值类型需与 go-redis 保持一致
+func testEvalResult(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		script    string
+		keys      []string
+		args      []interface{}
+		want      interface{}
+		wantInt   int64
+		wantIntOk bool
+	}{
+		{
+			name:      "整数",
+			script:    "return 42",
+			want:      int64(42),
+			wantInt:   42,
+			wantIntOk: true,
+		},
+		{
+			name:      "负整数",
+			script:    "return -6",
+			want:      int64(-6),
+			wantInt:   -6,
+			wantIntOk: true,
+		},
+		{
+			name:      "数字字符串",
+			script:    "return '7'",
+			want:      "7",
+			wantInt:   7,
+			wantIntOk: true,
+		},
+		{
+			name:   "非数字字符串",
+			script: "return '12abc'",
+			want:   "12abc",
+		},
+		{
+			name:   "空回复",
+			script: "return false",
+			want:   nil,
+		},
+		{
+			name:   "数组",
+			script: "return {-2, 4800, 'worker-7', {1}}",
+			want:   []interface{}{int64(-2), int64(4800), "worker-7", []interface{}{int64(1)}},
+		},
+		{
+			name:   "KEYS与ARGV",
+			script: "return {KEYS[1], KEYS[2], ARGV[1], ARGV[2]}",
+			keys:   []string{s.key("k1"), s.key("k2")},
+			args:   []interface{}{"token", 5000},
+			want:   []interface{}{s.key("k1"), s.key("k2"), "token", "5000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rdb.Eval(ctx, tt.script, tt.keys, tt.args...).Result()
+			// 空回复允许返回客户端自身的 nil 错误（如 redis.Nil），但不能返回非 nil 的值
+			if tt.want != nil && err != nil {
+				t.Fatalf("Result() returned unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Result() = %#v, want %#v", got, tt.want)
+			}
+
+			n, err := rdb.Eval(ctx, tt.script, tt.keys, tt.args...).Int64()
+			if tt.wantIntOk {
+				if err != nil {
+					t.Fatalf("Int64() returned unexpected error: %v", err)
+				}
+				if n != tt.wantInt {
+					t.Errorf("Int64() = %d, want %d", n, tt.wantInt)
+				}
+			} else if err == nil {
+				t.Errorf("Int64() returned %d, want an error", n)
+			}
+		})
+	}
+}
+
+// 脚本错误与命令错误需要原样返回
+func testEvalError(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+
+	// 准备一个 string 类型的 key，在其上执行 hash 与 INCR 命令会报错
+	if _, err := rdb.Eval(ctx, "return redis.call('SET', KEYS[1], 'abc')", []string{s.key("conformance")}).Result(); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+
+	for _, script := range []string{
+		"return redis.error_reply('ERR conformance')",
+		"return redis.call('INCR', KEYS[1])",
+	} {
+		if _, err := rdb.Eval(ctx, script, []string{s.key("conformance")}).Result(); err == nil {
+			t.Errorf("Result() of %q returned no error", script)
+		}
+		if _, err := rdb.Eval(ctx, script, []string{s.key("conformance")}).Int64(); err == nil {
+			t.Errorf("Int64() of %q returned no error", script)
+		}
+	}
+
+	// 锁操作中的客户端错误需要包装为 ErrException
+	err := redislock.New(rdb, s.key("conformance")).WLock(ctx)
+	mustIs(t, "WLock on a string key", err, redislock.ErrException)
+}
+
+// 已取消的上下文不能发出请求
+func testContextCanceled(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	key := s.key("canceled")
+	if _, err := rdb.Eval(ctx, "return redis.call('SET', KEYS[1], 1)", []string{key}).Result(); err == nil {
+		t.Errorf("Eval() with canceled context returned no error")
+	}
+	if err := redislock.New(rdb, key).Lock(ctx); err == nil {
+		t.Errorf("Lock() with canceled context returned no error")
+	}
+
+	res, err := rdb.Eval(context.Background(), "return redis.call('EXISTS', KEYS[1], KEYS[2])", []string{key, "{" + key + "}"}).Int64()
+	if err != nil {
+		t.Fatalf("Int64() returned unexpected error: %v", err)
+	}
+	if res != 0 {
+		t.Errorf("request with canceled context reached the server")
+	}
+}
+
+func testReentrantLock(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+	key := s.key("reentrant")
+	a := redislock.New(rdb, key, redislock.WithToken("worker-7"), redislock.WithTimeout(2*time.Second))
+	b := redislock.New(rdb, key, redislock.WithToken("worker-8"), redislock.WithTimeout(2*time.Second))
+
+	mustNil(t, "Lock", a.Lock(ctx))
+	mustNil(t, "Lock reentry", a.Lock(ctx))
+
+	err := b.Lock(ctx)
+	mustIs(t, "Lock by other", err, redislock.ErrLockHeld)
+	mustIs(t, "Lock by other", err, redislock.ErrLockFailed)
+
+	var lockErr *redislock.LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("Lock by other: expected *LockError, got %v", err)
+	}
+	if lockErr.Kind != redislock.KindReentrant || len(lockErr.Holders) != 1 || lockErr.Holders[0] != "worker-7" || lockErr.TTL <= 0 {
+		t.Errorf("Lock by other: unexpected LockError %+v", lockErr)
+	}
+
+	mustIs(t, "UnLock by other", b.UnLock(ctx), redislock.ErrNotOwner)
+	mustIs(t, "Renew by other", b.Renew(ctx), redislock.ErrNotOwner)
+	mustNil(t, "Renew", a.Renew(ctx))
+	mustNil(t, "UnLock", a.UnLock(ctx))
+	mustNil(t, "UnLock reentry", a.UnLock(ctx))
+	mustNil(t, "Lock after release", b.Lock(ctx))
+
+	s.advance(3 * time.Second)
+	mustIs(t, "UnLock after expiry", b.UnLock(ctx), redislock.ErrLockExpired)
+	mustNil(t, "Lock after expiry", a.Lock(ctx))
+}
+
+func testFairLock(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+	lock := redislock.New(rdb, s.key("fair"), redislock.WithRequestTimeout(3*time.Second), redislock.WithMaxQueueLength(3))
+
+	mustNil(t, "FairLock r1", lock.FairLock(ctx, "r1"))
+	s.advance(time.Second)
+	mustIs(t, "FairLock r2", lock.FairLock(ctx, "r2"), redislock.ErrNotQueueHead)
+	s.advance(time.Second)
+	mustIs(t, "FairLock r3", lock.FairLock(ctx, "r3"), redislock.ErrNotQueueHead)
+	mustIs(t, "FairLock r4", lock.FairLock(ctx, "r4"), redislock.ErrQueueFull)
+
+	mustNil(t, "FairRenew r1", lock.FairRenew(ctx, "r1"))
+	mustIs(t, "FairRenew r2", lock.FairRenew(ctx, "r2"), redislock.ErrLockRenewFailed)
+
+	mustNil(t, "FairCancel r2", lock.FairCancel(ctx, "r2"))
+	mustIs(t, "FairCancel r1", lock.FairCancel(ctx, "r1"), redislock.ErrFairCancelFailed)
+	mustNil(t, "FairUnLock r1", lock.FairUnLock(ctx, "r1"))
+
+	// r2 已离开队列，r3 直接获得锁
+	mustNil(t, "FairLock r3", lock.FairLock(ctx, "r3"))
+	s.advance(time.Second)
+	mustIs(t, "FairLock r1 again", lock.FairLock(ctx, "r1"), redislock.ErrNotQueueHead)
+}
+
+func testPriorityFairLock(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+	lock := redislock.New(rdb, s.key("priority"))
+
+	mustNil(t, "PriorityFairLock holder", lock.PriorityFairLock(ctx, "holder", 0))
+	mustIs(t, "PriorityFairLock low", lock.PriorityFairLock(ctx, "low", 0), redislock.ErrNotQueueHead)
+	mustIs(t, "PriorityFairLock high", lock.PriorityFairLock(ctx, "high", 10), redislock.ErrLockHeld)
+	mustIs(t, "PriorityFairLock invalid", lock.PriorityFairLock(ctx, "bad", redislock.MaxPriority+1), redislock.ErrInvalidPriority)
+
+	mustNil(t, "FairUnLock holder", lock.FairUnLock(ctx, "holder"))
+	mustIs(t, "PriorityFairLock low", lock.PriorityFairLock(ctx, "low", 0), redislock.ErrNotQueueHead)
+	mustNil(t, "PriorityFairLock high", lock.PriorityFairLock(ctx, "high", 10))
+}
+
+func testReadWriteLock(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+	key := s.key("rw")
+	a := redislock.New(rdb, key, redislock.WithToken("a"), redislock.WithTimeout(2*time.Second))
+	b := redislock.New(rdb, key, redislock.WithToken("b"), redislock.WithTimeout(2*time.Second))
+
+	mustNil(t, "RLock a", a.RLock(ctx))
+	mustNil(t, "RLock b", b.RLock(ctx))
+	mustIs(t, "WLock a with other readers", a.WLock(ctx), redislock.ErrWrongMode)
+	mustNil(t, "RUnLock b", b.RUnLock(ctx))
+
+	// 唯一的读者可以升级为写锁
+	mustNil(t, "WLock a", a.WLock(ctx))
+	mustIs(t, "RLock b during write", b.RLock(ctx), redislock.ErrWrongMode)
+	mustIs(t, "WUnLock b", b.WUnLock(ctx), redislock.ErrNotOwner)
+	mustNil(t, "WRenew a", a.WRenew(ctx))
+	mustNil(t, "WUnLock a", a.WUnLock(ctx))
+	mustNil(t, "RUnLock a", a.RUnLock(ctx))
+
+	mustNil(t, "WLock b", b.WLock(ctx))
+	s.advance(3 * time.Second)
+	mustIs(t, "WRenew after expiry", b.WRenew(ctx), redislock.ErrLockExpired)
+	mustNil(t, "RLock after expiry", a.RLock(ctx))
+}
+
+func mustNil(t *testing.T, op string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s returned unexpected error: %v", op, err)
+	}
+}
+
+func mustIs(t *testing.T, op string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("%s = %v, want %v", op, err, target)
+	}
+}
+
+// 联锁脚本尚未接入锁 API，直接按脚本验证
+func testMultiLockScripts(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+	scripts := redislock.Scripts()
+	keys := []string{s.key("multi")}
+
+	steps := []struct {
+		script string
+		args   []interface{}
+		want   int64
+	}{
+		{script: "multiLock", args: []interface{}{"a", 5000}, want: 1},
+		{script: "multiLock", args: []interface{}{"b", 5000}, want: -2},
+		{script: "multiRenew", args: []interface{}{"b", 5000}, want: -5},
+		{script: "multiRenew", args: []interface{}{"a", 5000}, want: 1},
+		{script: "multiUnLock", args: []interface{}{"b"}, want: -5},
+		{script: "multiUnLock", args: []interface{}{"a"}, want: 1},
+		{script: "multiUnLock", args: []interface{}{"a"}, want: -6},
+		{script: "multiLock", args: []interface{}{"b", 5000}, want: 1},
+	}
+
+	for _, step := range steps {
+		got, err := rdb.Eval(ctx, scripts[step.script], keys, step.args...).Int64()
+		if err != nil {
+			t.Fatalf("%s %v returned unexpected error: %v", step.script, step.args, err)
+		}
+		if got != step.want {
+			t.Fatalf("%s %v = %d, want %d", step.script, step.args, got, step.want)
+		}
+	}
+}
+
+// 批量续期脚本以数组返回每把锁的续期结果
+func testBatchRenew(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+	keys := []string{s.key("r"), s.key("f"), s.key("rw"), s.key("w"), s.key("missing")}
+
+	mustNil(t, "Lock", redislock.New(rdb, keys[0], redislock.WithToken("a")).Lock(ctx))
+	mustNil(t, "FairLock", redislock.New(rdb, keys[1]).FairLock(ctx, "a"))
+	mustNil(t, "RLock", redislock.New(rdb, keys[2], redislock.WithToken("a")).RLock(ctx))
+	mustNil(t, "WLock", redislock.New(rdb, keys[3], redislock.WithToken("a")).WLock(ctx))
+
+	res, err := rdb.Eval(ctx, redislock.Scripts()["batchRenew"], keys,
+		"reentrant", "a", 5000, "fair", "a", 5000, "read", "b", 5000, "write", "a", 5000, "fair", "a", 5000).Result()
+	if err != nil {
+		t.Fatalf("batchRenew returned unexpected error: %v", err)
+	}
+	want := []interface{}{int64(1), int64(1), int64(-5), int64(1), int64(-6)}
+	if !reflect.DeepEqual(res, want) {
+		t.Fatalf("batchRenew = %#v, want %#v", res, want)
+	}
+}
+
+// 适配器实现 RedisFunctionInter 时，函数库可加载并通过 FCALL 调用；
+// 服务端不支持 Functions（Redis 7.0 以下、KeyDB、miniredis）时跳过，锁操作回退为 EVAL 由其他用例覆盖
+func testFunctions(t *testing.T, rdb redislock.RedisInter, s *server) {
+	fc, ok := rdb.(redislock.RedisFunctionInter)
+	if !ok {
+		t.Skip("adapter does not implement RedisFunctionInter")
+	}
+
+	ctx := context.Background()
+	library, code := redislock.FunctionLibrary()
+	if err := fc.FunctionLoad(ctx, code); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+			t.Skip("server does not support Redis Functions")
+		}
+		t.Fatalf("FunctionLoad() returned unexpected error: %v", err)
+	}
+
+	version, err := fc.FCall(ctx, library+"_version", nil).Result()
+	if want := strings.TrimPrefix(library, "redislock_"); err != nil || version != want {
+		t.Errorf("version = (%v, %v), want %q", version, err, want)
+	}
+
+	key := s.key("functions")
+	got, err := fc.FCall(ctx, library+"_reentrantLock", []string{key}, "token", 5000).Int64()
+	if err != nil || got != 1 {
+		t.Errorf("reentrantLock = (%d, %v), want 1", got, err)
+	}
+	if _, err = fc.FCall(ctx, library+"_missing", nil).Result(); err == nil {
+		t.Error("FCall() on missing function returned nil error")
+	}
+
+	// 锁 API 通过 FCALL 操作同一把锁
+	other := redislock.New(rdb, key, redislock.WithToken("other"))
+	mustIs(t, "UnLock by other", other.UnLock(ctx), redislock.ErrNotOwner)
+	mustNil(t, "Lock reentry", redislock.New(rdb, key, redislock.WithToken("token")).Lock(ctx))
+	mustNil(t, "UnLock", redislock.New(rdb, key, redislock.WithToken("token")).UnLock(ctx))
+}
+
+// 适配器实现 RedisWaitInter 时，command 与 WAIT 在同一连接上执行；
+// 测试服务端没有副本，WAIT 返回 0，WithMinReplicas(1) 的加锁被回滚
+func testMinReplicas(t *testing.T, rdb redislock.RedisInter, s *server) {
+	rw, ok := rdb.(redislock.RedisWaitInter)
+	if !ok {
+		t.Skip("adapter does not implement RedisWaitInter")
+	}
+
+	ctx := context.Background()
+	key := s.key("replicas")
+	cmd, acked, err := rw.DoWait(ctx, []interface{}{"EVAL", "return redis.call('SET', KEYS[1], ARGV[1])", 1, key, "v"}, 0, 10*time.Millisecond)
+	if err != nil || acked != 0 {
+		t.Fatalf("DoWait() = (%d, %v), want (0, nil)", acked, err)
+	}
+	if got, err := cmd.Result(); err != nil || got != "OK" {
+		t.Errorf("Result() = (%v, %v), want OK", got, err)
+	}
+
+	// 空命令返回错误，返回的 cmd 非 nil 且携带同一错误，调用方可直接链式调用 Result
+	cmd, _, err = rw.DoWait(ctx, nil, 0, 10*time.Millisecond)
+	if err == nil || cmd == nil {
+		t.Fatalf("DoWait(empty) = (%v, %v), want non-nil cmd and error", cmd, err)
+	}
+	if _, err := cmd.Result(); err == nil {
+		t.Error("Result() of empty DoWait returned nil error")
+	}
+
+	lock := redislock.New(rdb, key+":lock", redislock.WithToken("a"), redislock.WithMinReplicas(1, 50*time.Millisecond))
+	err = lock.Lock(ctx)
+	mustIs(t, "Lock", err, redislock.ErrNotEnoughReplicas)
+	mustIs(t, "Lock", err, redislock.ErrLockFailed)
+	mustNil(t, "Lock after rollback", redislock.New(rdb, key+":lock", redislock.WithToken("b")).Lock(ctx))
+}
+
+// 适配器实现 RedisPubSubInter 时，订阅确认后返回，脚本中 PUBLISH 的消息可被收到，取消订阅后不再有订阅者
+func testSubscribe(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ps, ok := rdb.(redislock.RedisPubSubInter)
+	if !ok {
+		t.Skip("adapter does not implement RedisPubSubInter")
+	}
+
+	ctx := context.Background()
+	key := s.key("subscribe")
+	notify := redislock.Scripts()["singleflightNotify"]
+
+	messages, unsubscribe, err := ps.Subscribe(ctx, "{"+key+"}:result")
+	if err != nil {
+		t.Fatalf("Subscribe() returned unexpected error: %v", err)
+	}
+	if n, err := rdb.Eval(ctx, notify, []string{key}).Int64(); err != nil || n != 1 {
+		t.Fatalf("publish = (%d, %v), want 1 subscriber", n, err)
+	}
+	select {
+	case <-messages:
+	case <-time.After(time.Second):
+		t.Fatal("expected message after publish")
+	}
+
+	// 取消订阅可能是异步的
+	unsubscribe()
+	deadline := time.Now().Add(time.Second)
+	for {
+		n, err := rdb.Eval(ctx, notify, []string{key}).Int64()
+		if err == nil && n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("publish after unsubscribe = (%d, %v), want 0 subscribers", n, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// singleflight 端到端：获胜者的结果写入共享结果 key，之后的调用直接复用
+func testSingleflight(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+	group := redislock.NewGroup(rdb, time.Minute)
+	key := s.key("singleflight")
+
+	var calls int
+	fn := func(ctx context.Context) ([]byte, error) {
+		calls++
+		return []byte("value"), nil
+	}
+	for i := 0; i < 2; i++ {
+		val, err := group.Do(ctx, key, fn)
+		if err != nil || string(val) != "value" {
+			t.Fatalf("Do() = (%q, %v), want value", val, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fn ran %d times, want 1", calls)
+	}
+
+	errBiz := errors.New("rebuild failed")
+	_, err := group.Do(ctx, key+":err", func(ctx context.Context) ([]byte, error) { return nil, errBiz })
+	mustIs(t, "Do", err, errBiz)
+	_, err = group.Do(ctx, key+":err", fn)
+	mustIs(t, "Do shared error", err, redislock.ErrSharedCallFailed)
+}
+
+// 选主端到端：当选后可查询 leader 信息，退位后其他候选者当选，任期递增
+func testElection(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+	key := s.key("election")
+	a := redislock.NewElection(rdb, key, "meta-a", redislock.WithToken("a"))
+	b := redislock.NewElection(rdb, key, "meta-b", redislock.WithToken("b"))
+
+	_, err := a.Leader(ctx)
+	mustIs(t, "Leader", err, redislock.ErrNoLeader)
+	mustNil(t, "Campaign", a.Campaign(ctx))
+
+	leader, err := b.Leader(ctx)
+	if want := (redislock.LeaderInfo{ID: "a", Metadata: "meta-a", Term: 1}); err != nil || leader != want {
+		t.Fatalf("Leader() = (%+v, %v), want %+v", leader, err, want)
+	}
+
+	elected := make(chan error, 1)
+	go func() { elected <- b.Campaign(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+	mustNil(t, "Resign", a.Resign(ctx))
+
+	select {
+	case err = <-elected:
+		mustNil(t, "Campaign", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected b to be elected after resign")
+	}
+	if b.Term() != 2 {
+		t.Errorf("Term() = %d, want 2", b.Term())
+	}
+	mustNil(t, "Resign", b.Resign(ctx))
+}
+
+// CountDownLatch 端到端：计数归零时等待者返回，归零后保留完成标记，可重新设置
+func testCountDownLatch(t *testing.T, rdb redislock.RedisInter, s *server) {
+	ctx := context.Background()
+	latch := redislock.NewCountDownLatch(rdb, s.key("latch"), time.Minute)
+
+	if ok, err := latch.TrySetCount(ctx, 2); err != nil || !ok {
+		t.Fatalf("TrySetCount() = (%v, %v), want true", ok, err)
+	}
+	if ok, err := latch.TrySetCount(ctx, 3); err != nil || ok {
+		t.Errorf("TrySetCount() on existing latch = (%v, %v), want false", ok, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		ok, err := latch.Await(ctx, 2*time.Second)
+		if err == nil && !ok {
+			err = errors.New("await timed out")
+		}
+		done <- err
+	}()
+
+	mustNil(t, "CountDown", latch.CountDown(ctx))
+	if count, err := latch.GetCount(ctx); err != nil || count != 1 {
+		t.Errorf("GetCount() = (%d, %v), want 1", count, err)
+	}
+	mustNil(t, "CountDown", latch.CountDown(ctx))
+	mustNil(t, "Await", <-done)
+
+	if count, err := latch.GetCount(ctx); err != nil || count != 0 {
+		t.Errorf("GetCount() after zero = (%d, %v), want 0", count, err)
+	}
+	if ok, err := latch.Await(ctx, time.Second); err != nil || !ok {
+		t.Errorf("Await() after zero = (%v, %v), want true", ok, err)
+	}
+	if ok, err := latch.TrySetCount(ctx, 1); err != nil || !ok {
+		t.Errorf("TrySetCount() after zero = (%v, %v), want true", ok, err)
+	}
+}
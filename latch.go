@@ -0,0 +1,182 @@
+package go_redislock
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	//go:embed lua/latchTrySet.lua
+	latchTrySetScript string
+	//go:embed lua/latchCountDown.lua
+	latchCountDownScript string
+	//go:embed lua/latchGet.lua
+	latchGetScript string
+)
+
+// CountDownLatch 分布式 CountDownLatch：TrySetCount 设置计数，各进程 CountDown 计数减 1，
+// Await 阻塞直到计数归零。计数保存在 {key}:latch 中，设置与每次 CountDown 时刷新有效期，
+// 被放弃的 latch 到期后自动删除；归零后计数为 0 的 latch 保留 ttl 作为完成标记
+//
+//	latch := redislock.NewCountDownLatch(rdb, "job:42", time.Hour)
+//	_, _ = latch.TrySetCount(ctx, 8)
+//	// 每个分片完成后
+//	_ = latch.CountDown(ctx)
+//	// 等待所有分片完成
+//	done, err := latch.Await(ctx, 10*time.Minute)
+type CountDownLatch struct {
+	lock *RedisLock
+	ttl  time.Duration
+	// 创建时的配置错误，各方法直接返回
+	err error
+}
+
+// NewCountDownLatch creates a distributed CountDownLatch expiring ttl after the last update
+// NewCountDownLatch 创建分布式 CountDownLatch，latch 在最后一次设置或 CountDown 之后 ttl 到期。
+// ttl 需不小于 1ms，否则各方法返回 ErrInvalidTTL。
+// options 中与 key 相关的配置（如 WithKeyPrefix）、日志、监控、Redis Functions 等配置生效
+func NewCountDownLatch(redisClient RedisInter, key string, ttl time.Duration, options ...Option) *CountDownLatch {
+	return newCountDownLatch(newRedisLock(redisClient, key, options...), ttl)
+}
+
+// NewCountDownLatch creates a distributed CountDownLatch with the client defaults, see NewCountDownLatch
+// NewCountDownLatch 使用客户端默认配置创建分布式 CountDownLatch，行为与 NewCountDownLatch 一致
+func (c *Client) NewCountDownLatch(key string, ttl time.Duration, options ...Option) *CountDownLatch {
+	return newCountDownLatch(c.newLock(key, options), ttl)
+}
+
+func newCountDownLatch(lock *RedisLock, ttl time.Duration) *CountDownLatch {
+	latch := &CountDownLatch{lock: lock, ttl: ttl}
+	// 有效期以毫秒写入，小于 1ms 时无法设置有效期，CountDown 的 PEXPIRE 0 还会直接删除 latch
+	if ttl < time.Millisecond {
+		latch.err = ErrInvalidTTL
+	}
+	return latch
+}
+
+// TrySetCount sets the count when the latch does not exist
+// TrySetCount 在 latch 不存在（未设置或已过期）或已归零时设置计数，返回是否设置成功。count 需大于 0
+func (c *CountDownLatch) TrySetCount(ctx context.Context, count int64) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	if count <= 0 {
+		return false, ErrInvalidCount
+	}
+
+	res, err := c.lock.eval(ctx, latchTrySetScript,
+		[]string{c.lock.key},
+		count,
+		c.ttl.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return false, exceptionErr(err)
+	}
+	return res == codeOK, nil
+}
+
+// CountDown decrements the count, waking up awaiting processes when it reaches zero
+// CountDown 计数减 1 并刷新有效期，归零时唤醒等待者。latch 不存在或已归零时不做任何操作
+func (c *CountDownLatch) CountDown(ctx context.Context) error {
+	if c.err != nil {
+		return c.err
+	}
+	if _, err := c.lock.eval(ctx, latchCountDownScript,
+		[]string{c.lock.key},
+		c.ttl.Milliseconds(),
+	).Int64(); err != nil {
+		return exceptionErr(err)
+	}
+	return nil
+}
+
+// GetCount returns the remaining count, 0 when the latch does not exist
+// GetCount 返回剩余计数，latch 已归零或不存在（未设置或已过期）时返回 0
+func (c *CountDownLatch) GetCount(ctx context.Context) (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	count, _, _, err := c.get(ctx)
+	return count, err
+}
+
+// Await waits until the count reaches zero, reporting false when timeout elapses first
+// Await 阻塞直到计数归零（返回 true）或超过 timeout（返回 false），timeout 小于等于 0 时只受 ctx 限制。
+// latch 已归零（完成标记未过期）时立即返回 true；latch 不存在（未设置，或计数未归零时已过期）返回 ErrLatchExpired，
+// 因此需在 TrySetCount 之后调用 Await，先于 TrySetCount 调用时返回 ErrLatchExpired。
+// 客户端实现 RedisPubSubInter 时归零通知立即唤醒等待者，否则轮询等待
+func (c *CountDownLatch) Await(ctx context.Context, timeout time.Duration) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	// 先订阅再查询计数，避免查询与订阅之间的通知丢失
+	sub := subscribe(ctx, c.lock.redis, latchChannel(c.lock.key))
+	defer sub.close()
+
+	for {
+		count, ttl, found, err := c.get(ctx)
+		if err != nil {
+			return false, err
+		}
+		if !found {
+			return false, ErrLatchExpired
+		}
+		if count <= 0 {
+			return true, nil
+		}
+
+		// latch 到期时重新查询，返回 ErrLatchExpired
+		wait := c.ttl
+		if ttl > 0 {
+			wait = min(wait, ttl)
+		}
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return false, nil
+			}
+			wait = min(wait, remaining)
+		}
+
+		if err = sub.wait(ctx, wait); err != nil {
+			return false, err
+		}
+	}
+}
+
+// get 查询剩余计数与剩余有效期，found 表示 latch 存在（包括已归零的完成标记）
+func (c *CountDownLatch) get(ctx context.Context) (count int64, ttl time.Duration, found bool, err error) {
+	res, err := c.lock.eval(ctx, latchGetScript, []string{c.lock.key}).Result()
+	if err != nil {
+		return 0, 0, false, exceptionErr(err)
+	}
+
+	arr, ok := res.([]interface{})
+	if !ok || len(arr) < 2 {
+		return 0, 0, false, errors.Join(fmt.Errorf("unexpected latch result: %v", res), ErrException)
+	}
+	count, err = toInt64(arr[0])
+	if err != nil {
+		return 0, 0, false, exceptionErr(err)
+	}
+	pttl, err := toInt64(arr[1])
+	if err != nil {
+		return 0, 0, false, exceptionErr(err)
+	}
+	// PTTL 返回 -2 表示 key 不存在
+	return count, time.Duration(pttl) * time.Millisecond, pttl != -2, nil
+}
+
+// latchChannel 计数归零的通知频道，与 latch key 同名
+func latchChannel(key string) string {
+	return "{" + key + "}:latch"
+}
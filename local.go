@@ -0,0 +1,102 @@
+package go_redislock
+
+import (
+	"errors"
+	"sync"
+)
+
+// UnavailablePolicy 熔断器熔断（Redis 不可用）时普通锁的处理策略
+type UnavailablePolicy int
+
+const (
+	// FailClosed 加锁失败并返回 ErrRedisUnavailable，默认策略
+	FailClosed UnavailablePolicy = iota
+	// FallbackLocal 回退为进程内的可重入互斥锁：同一进程内仍然互斥，跨进程不再互斥，只适用于非关键的 key
+	FallbackLocal
+)
+
+// WithUnavailablePolicy sets how Lock behaves when the circuit breaker reports ErrRedisUnavailable, FailClosed by default
+// WithUnavailablePolicy 设置熔断器返回 ErrRedisUnavailable 时普通锁（Lock/SpinLock）的处理策略，默认为 FailClosed
+func WithUnavailablePolicy(policy UnavailablePolicy) Option {
+	return func(lock *RedisLock) {
+		lock.unavailablePolicy = policy
+	}
+}
+
+// localLock 进程内的可重入锁
+type localLock struct {
+	token string
+	count int
+}
+
+// localLocks 进程内锁，按锁的 key 区分
+var localLocks = struct {
+	sync.Mutex
+	locks map[string]*localLock
+}{locks: make(map[string]*localLock)}
+
+// localFallback Redis 不可用且策略为 FallbackLocal 时以进程内锁代替，返回 false 表示不回退
+func (l *RedisLock) localFallback(err error) (bool, error) {
+	if l.unavailablePolicy != FallbackLocal || !errors.Is(err, ErrRedisUnavailable) {
+		return false, nil
+	}
+
+	localLocks.Lock()
+	defer localLocks.Unlock()
+
+	local, ok := localLocks.locks[l.key]
+	if ok && local.token != l.token {
+		return true, codeErr(ErrLockFailed, codeLockHeld)
+	}
+	if !ok {
+		local = &localLock{token: l.token}
+		localLocks.locks[l.key] = local
+	}
+	local.count++
+
+	l.logf("Warn: redis unavailable, %s locked in process-local mode", l.key)
+	return true, nil
+}
+
+// localHeldByOthers 进程内锁被其他持有者占用，Redis 恢复后仍需等待其释放
+func (l *RedisLock) localHeldByOthers() bool {
+	if l.unavailablePolicy != FallbackLocal {
+		return false
+	}
+
+	localLocks.Lock()
+	defer localLocks.Unlock()
+	local, ok := localLocks.locks[l.key]
+	return ok && local.token != l.token
+}
+
+// localUnLock 释放进程内锁，返回 false 表示当前未持有进程内锁
+func (l *RedisLock) localUnLock() bool {
+	if l.unavailablePolicy != FallbackLocal {
+		return false
+	}
+
+	localLocks.Lock()
+	defer localLocks.Unlock()
+
+	local, ok := localLocks.locks[l.key]
+	if !ok || local.token != l.token {
+		return false
+	}
+	if local.count--; local.count == 0 {
+		delete(localLocks.locks, l.key)
+	}
+	return true
+}
+
+// localHeld 当前是否持有进程内锁
+func (l *RedisLock) localHeld() bool {
+	if l.unavailablePolicy != FallbackLocal {
+		return false
+	}
+
+	localLocks.Lock()
+	defer localLocks.Unlock()
+	local, ok := localLocks.locks[l.key]
+	return ok && local.token == l.token
+}
@@ -3,6 +3,7 @@ package go_redislock
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,6 +20,15 @@ type RedisCmd interface {
 	Int64() (int64, error)
 }
 
+// RedisFunctionInter 支持 Redis Functions（Redis 7.0+）的客户端接口，适配器可选实现。
+// 实现后锁操作通过 FCALL 调用函数库中的函数，服务端不支持时自动回退为 EVAL
+type RedisFunctionInter interface {
+	// FunctionLoad 加载函数库，语义与 FUNCTION LOAD REPLACE 一致
+	FunctionLoad(ctx context.Context, code string) error
+	// FCall 调用函数库中的函数
+	FCall(ctx context.Context, function string, keys []string, args ...interface{}) RedisCmd
+}
+
 // type RedisInter interface {
 // 	redis.Scripter
 // }
@@ -38,10 +48,17 @@ type RedisLockInter interface {
 	FairLock(ctx context.Context, requestId string) error
 	// SpinFairLock 自旋公平锁
 	SpinFairLock(ctx context.Context, requestId string, timeout time.Duration) error
-	// FairUnLock 公平锁解锁
+	// FairUnLock 公平锁解锁（幂等，不返回 ErrNotOwner、ErrLockExpired）
 	FairUnLock(ctx context.Context, requestId string) error
 	// FairRenew 公平锁续期
 	FairRenew(ctx context.Context, requestId string) error
+	// FairCancel 公平锁取消排队
+	FairCancel(ctx context.Context, requestId string) error
+
+	// PriorityFairLock 优先级公平锁加锁
+	PriorityFairLock(ctx context.Context, requestId string, priority int) error
+	// SpinPriorityFairLock 自旋优先级公平锁
+	SpinPriorityFairLock(ctx context.Context, requestId string, priority int, timeout time.Duration) error
 
 	// RLock 读锁加锁
 	RLock(ctx context.Context) error
@@ -71,6 +88,23 @@ type RedisLockInter interface {
 	// MultiRenew(ctx context.Context, locks []RedisLockInter) error
 }
 
+// RedisFairLockInter defines a fair lock handle that manages its own requestId
+// RedisFairLockInter 公平锁句柄接口，句柄内部自动生成并保存 requestId
+type RedisFairLockInter interface {
+	// Lock 公平锁加锁
+	Lock(ctx context.Context) error
+	// SpinLock 自旋公平锁
+	SpinLock(ctx context.Context, timeout time.Duration) error
+	// UnLock 公平锁解锁
+	UnLock(ctx context.Context) error
+	// Renew 公平锁续期
+	Renew(ctx context.Context) error
+	// Cancel 公平锁取消排队
+	Cancel(ctx context.Context) error
+	// RequestId 返回句柄使用的 requestId
+	RequestId() string
+}
+
 type RedisLock struct {
 	redis           RedisInter
 	key             string
@@ -78,32 +112,83 @@ type RedisLock struct {
 	lockTimeout     time.Duration
 	isAutoRenew     bool
 	requestTimeout  time.Duration
+	maxQueueLength  int64
+	priorityAging   time.Duration
 	autoRenewCancel context.CancelFunc
+	// 是否禁用 Redis Functions，禁用后始终使用 EVAL
+	disableFunctions bool
+	// 加锁、续期后需通过 WAIT 确认写入的最少副本数，0 表示不等待
+	minReplicas int
+	// WAIT 的最长等待时间
+	replicaTimeout time.Duration
+	// 自动续期遇到故障切换错误时的最长重试时间
+	failoverGrace time.Duration
+	// Redis 不可用时普通锁的处理策略
+	unavailablePolicy UnavailablePolicy
+	// 解锁、续期遇到临时错误时的重试次数与初始退避时间
+	retryAttempts int
+	retryBackoff  time.Duration
+	// 锁 key 的前缀
+	keyPrefix string
+	// 日志，为 nil 时使用标准库 log
+	logger Logger
+	// 监控回调
+	metrics Metrics
+	// 共享续期调度器，由 Client 创建的锁使用
+	scheduler *renewScheduler
+	// 函数库加载状态，由 Client 创建的锁共享；为 nil 时使用 Redis 客户端共享的状态
+	functions *functionState
+	// 自动续期的生命周期：renewCtx 非空时续期随其结束，renewUntilUnLock 时只在 UnLock 时停止，否则随加锁的 ctx 结束
+	renewCtx         context.Context
+	renewUntilUnLock bool
+	// 自动续期单次续期的超时时间
+	renewTimeout time.Duration
+	// 自动续期的间隔
+	renewInterval time.Duration
+	// 续期时设置的 TTL
+	renewTTL time.Duration
+	// 自动续期可容忍的连续失败次数
+	maxRenewFailures int
+	// 自动续期失败回调
+	onRenewFailure func(failure RenewFailure)
+	// 自动续期判定锁丢失时的内部回调，由 Do 等辅助函数设置
+	onLost atomic.Pointer[func(err error)]
 }
 
 type Option func(lock *RedisLock)
 
 // New creates a RedisLock instance
 func New(redisClient RedisInter, lockKey string, options ...Option) RedisLockInter {
+	return newRedisLock(redisClient, lockKey, options...)
+}
+
+func newRedisLock(redisClient RedisInter, lockKey string, options ...Option) *RedisLock {
 	lock := &RedisLock{
 		redis:          redisClient,
 		lockTimeout:    lockTime,       // 锁默认超时时间
 		requestTimeout: requestTimeout, // 公平锁在队列中的最大等待时间
+		retryAttempts:  retryAttempts,  // 解锁、续期遇到临时错误时的重试次数
+		retryBackoff:   retryBackoff,
 	}
 
 	for _, f := range options {
 		f(lock)
 	}
-	lock.key = lockKey
+	lock.key = lock.keyPrefix + lockKey
 
 	// 如果未设置锁的Token，则生成一个唯一的Token
 	if lock.token == "" {
-		lock.token = fmt.Sprintf("lock_token:%s", uuid.New().String())
+		lock.token = newToken()
 	}
 
 	return lock
 }
 
+// 生成唯一的锁 Token
+func newToken() string {
+	return fmt.Sprintf("lock_token:%s", uuid.New().String())
+}
+
 // WithTimeout sets the expiration time of the lock
 // WithTimeout 设置锁的过期时间
 func WithTimeout(timeout time.Duration) Option {
@@ -135,3 +220,19 @@ func WithRequestTimeout(timeout time.Duration) Option {
 		lock.requestTimeout = timeout
 	}
 }
+
+// WithMaxQueueLength limits the length of the fair lock queue, new requests are rejected with ErrQueueFull when it is full
+// WithMaxQueueLength 设置公平锁排队队列的最大长度，队列已满时新请求直接返回 ErrQueueFull（0 表示不限制）
+func WithMaxQueueLength(length int64) Option {
+	return func(lock *RedisLock) {
+		lock.maxQueueLength = length
+	}
+}
+
+// WithPriorityAging enables aging for the priority fair lock, each period waited counts as one priority level
+// WithPriorityAging 开启优先级公平锁的老化机制，请求每等待一个周期相当于优先级提升一级，避免低优先级请求饥饿
+func WithPriorityAging(period time.Duration) Option {
+	return func(lock *RedisLock) {
+		lock.priorityAging = period
+	}
+}
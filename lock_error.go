@@ -0,0 +1,131 @@
+package go_redislock
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockKind 锁类型
+type LockKind string
+
+const (
+	// KindReentrant 普通锁（可重入）
+	KindReentrant LockKind = "reentrant"
+	// KindFair 公平锁
+	KindFair LockKind = "fair"
+	// KindPriority 优先级公平锁
+	KindPriority LockKind = "priority"
+	// KindRead 读锁
+	KindRead LockKind = "read"
+	// KindWrite 写锁
+	KindWrite LockKind = "write"
+)
+
+// LockError describes a failed lock acquisition, use errors.As to get it.
+// Key, holders and remaining TTL are filled in atomically by the acquisition script.
+//
+// LockError 加锁失败的详细信息，可通过 errors.As 获取。
+// 当前持有者与剩余有效期由加锁脚本在同一次原子操作中返回。
+type LockError struct {
+	// Key 锁的 key
+	Key string
+	// Kind 锁类型
+	Kind LockKind
+	// Holders 当前持有者的 Token / requestId（读锁可能有多个，锁空闲时为空）
+	Holders []string
+	// TTL 锁剩余有效期（锁不存在时为 0，未设置过期时间时为 -1ms）
+	TTL time.Duration
+	// Err 失败原因，满足 errors.Is(err, ErrLockFailed)
+	Err error
+}
+
+func (e *LockError) Error() string {
+	if len(e.Holders) == 0 {
+		return fmt.Sprintf("%v: %s %s lock", e.Err, e.Key, e.Kind)
+	}
+
+	holders := strings.Join(e.Holders, ",")
+	if e.TTL < 0 {
+		return fmt.Sprintf("%v: %s %s lock held by %s without expiration", e.Err, e.Key, e.Kind, holders)
+	}
+	return fmt.Sprintf("%v: %s %s lock held by %s for %s more", e.Err, e.Key, e.Kind, holders, e.TTL)
+}
+
+func (e *LockError) Unwrap() error {
+	return e.Err
+}
+
+// lockErr 解析加锁脚本返回值并转换为错误，加锁成功返回 nil
+// 加锁脚本成功返回 1，失败返回 {code, pttl, holder...}
+func (l *RedisLock) lockErr(kind LockKind, res interface{}) error {
+	var (
+		code    int64
+		pttl    int64 = -2
+		holders []string
+		err     error
+	)
+
+	switch v := res.(type) {
+	case []interface{}:
+		if len(v) < 2 {
+			return errors.Join(fmt.Errorf("unexpected lock result: %v", v), ErrException)
+		}
+		if code, err = toInt64(v[0]); err != nil {
+			return exceptionErr(err)
+		}
+		if pttl, err = toInt64(v[1]); err != nil {
+			return exceptionErr(err)
+		}
+		for _, holder := range v[2:] {
+			switch h := holder.(type) {
+			case string:
+				holders = append(holders, h)
+			case []byte:
+				holders = append(holders, string(h))
+			}
+		}
+	default:
+		if code, err = toInt64(v); err != nil {
+			return exceptionErr(err)
+		}
+	}
+
+	if code == codeOK {
+		return nil
+	}
+
+	lockErr := &LockError{
+		Key:     l.key,
+		Kind:    kind,
+		Holders: holders,
+		Err:     codeErr(ErrLockFailed, code),
+	}
+	// PTTL 返回 -2 表示锁不存在，-1 表示未设置过期时间
+	switch {
+	case pttl >= 0:
+		lockErr.TTL = time.Duration(pttl) * time.Millisecond
+	case pttl == -1:
+		lockErr.TTL = -time.Millisecond
+	}
+
+	return lockErr
+}
+
+// toInt64 将脚本返回的整数转换为 int64，兼容不同 Redis 客户端的返回类型
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case int:
+		return int64(n), nil
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	case []byte:
+		return strconv.ParseInt(string(n), 10, 64)
+	default:
+		return 0, fmt.Errorf("cannot convert result to int: %T", v)
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"errors"
-	"log"
+	"fmt"
 	"time"
 )
 
@@ -15,33 +15,42 @@ var (
 	fairUnLockScript string
 	//go:embed lua/fairRenew.lua
 	fairRenewScript string
+	//go:embed lua/fairCancel.lua
+	fairCancelScript string
 )
 
 // FairLock 公平锁尝试加锁（使用指定的 requestId 获取公平锁）
 // FairLock tries to acquire a fair lock using the given requestId.
 // 公平锁确保请求按照顺序获取锁，避免饥饿现象
-// 如果是队首且成功获取锁则返回 nil，否则返回 ErrLockFailed
+// 如果是队首且成功获取锁则返回 nil，否则返回 ErrLockFailed，
+// 并可通过 errors.Is 区分具体原因：ErrQueueFull（队列已满）、ErrNotQueueHead（未轮到）、ErrLockHeld（队首但锁仍被占用）
 func (l *RedisLock) FairLock(ctx context.Context, requestId string) error {
-	result, err := l.redis.Eval(ctx, fairLockScript,
+	cmd, replicaErr := l.evalReplicated(ctx, fairLockScript,
 		[]string{l.key},
 		requestId,
 		l.lockTimeout.Milliseconds(),
 		l.requestTimeout.Milliseconds(),
-	).Int64()
+		l.maxQueueLength,
+	)
+	result, err := cmd.Result()
 
 	if err != nil {
-		return errors.Join(err, ErrException)
+		return exceptionErr(err)
 	}
 
 	// 没有抢到锁，则进入排队，不是ok则说明不是队首
-	if result != 1 {
-		return ErrLockFailed
+	if err = l.lockErr(KindFair, result); err != nil {
+		return err
+	}
+	if replicaErr != nil {
+		l.rollback(ctx, fairUnLockScript, requestId)
+		return fmt.Errorf("%w: %w", ErrLockFailed, replicaErr)
 	}
 
 	if l.isAutoRenew {
-		ctxRenew, cancel := context.WithCancel(ctx)
-		l.autoRenewCancel = cancel
-		go l.autoFairRenew(ctxRenew, requestId)
+		l.startAutoRenew(ctx, KindFair, requestId, "autoFairRenew", func(ctx context.Context) error {
+			return l.FairRenew(ctx, requestId)
+		})
 	}
 
 	return nil
@@ -50,21 +59,36 @@ func (l *RedisLock) FairLock(ctx context.Context, requestId string) error {
 // SpinFairLock keeps trying to acquire a fair lock until timeout.
 // SpinFairLock 在指定超时时间内不断尝试获取公平锁。
 func (l *RedisLock) SpinFairLock(ctx context.Context, requestId string, timeout time.Duration) error {
+	return l.spinFair(ctx, requestId, timeout, func() error {
+		return l.FairLock(ctx, requestId)
+	})
+}
+
+// 公平锁自旋加锁，tryLock 为单次加锁操作
+// 自旋超时或 ctx 取消时离开排队队列，队列已满时快速失败
+func (l *RedisLock) spinFair(ctx context.Context, requestId string, timeout time.Duration, tryLock func() error) error {
 	exp := time.Now().Add(timeout)
 	for {
 		// 检查自旋锁是否超时
 		if time.Now().After(exp) {
+			l.leaveFairQueue(ctx, requestId)
 			return ErrSpinLockTimeout
 		}
 
 		// 尝试公平锁锁成功
-		if err := l.FairLock(ctx, requestId); err == nil {
+		err := tryLock()
+		if err == nil {
 			return nil
 		}
+		// 队列已满，快速失败，不再重试
+		if errors.Is(err, ErrQueueFull) {
+			return err
+		}
 
 		// 如果加锁失败，则休眠一段时间再尝试
 		select {
 		case <-ctx.Done(): // 检查上下文是否已取消
+			l.leaveFairQueue(ctx, requestId)
 			return errors.Join(ErrSpinLockDone, context.Canceled)
 		case <-time.After(100 * time.Millisecond):
 			// 继续尝试下一轮加锁
@@ -73,67 +97,80 @@ func (l *RedisLock) SpinFairLock(ctx context.Context, requestId string, timeout
 }
 
 // FairUnLock releases the fair lock held by the given requestId.
+// It is idempotent: it never reports ErrNotOwner or ErrLockExpired, a requestId that does not hold the lock is only removed from the queue.
+//
 // FairUnLock 根据 requestId 释放公平锁。
+// 解锁是幂等的，不会返回 ErrNotOwner 或 ErrLockExpired：requestId 未持有锁（锁已过期或由其他请求持有）时
+// 只将其移出等待队列并返回 nil，因此无法通过返回值判断锁是否在解锁前已丢失。
 func (l *RedisLock) FairUnLock(ctx context.Context, requestId string) error {
 	if l.autoRenewCancel != nil {
 		l.autoRenewCancel()
 	}
 
-	result, err := l.redis.Eval(
-		ctx,
-		fairUnLockScript,
-		[]string{l.key},
-		requestId,
-	).Int64()
+	// 公平锁解锁脚本本身是幂等的，无需去重
+	return l.release(ctx, fairUnLockScript, requestId)
+}
+
+// FairRenew manually extends the expiration of a fair lock.
+// FairRenew 手动延长指定 requestId 的公平锁有效期。
+func (l *RedisLock) FairRenew(ctx context.Context, requestId string) error {
+	var replicaErr error
+	cmd := l.retry(ctx, func() RedisCmd {
+		var cmd RedisCmd
+		cmd, replicaErr = l.evalReplicated(
+			ctx,
+			fairRenewScript,
+			[]string{l.key},
+			requestId,
+			l.renewLease().Milliseconds(),
+		)
+		return cmd
+	})
+	res, err := cmd.Int64()
 
 	if err != nil {
-		return errors.Join(err, ErrException)
+		return exceptionErr(err)
 	}
 
-	if result != 1 {
-		return ErrUnLockFailed
+	if res != codeOK {
+		return codeErr(ErrLockRenewFailed, res)
+	}
+	if replicaErr != nil {
+		return fmt.Errorf("%w: %w", ErrLockRenewFailed, replicaErr)
 	}
 
 	return nil
 }
 
-// FairRenew manually extends the expiration of a fair lock.
-// FairRenew 手动延长指定 requestId 的公平锁有效期。
-func (l *RedisLock) FairRenew(ctx context.Context, requestId string) error {
-	res, err := l.redis.Eval(
+// FairCancel removes the given requestId from the fair lock queue without waiting for it to time out.
+// FairCancel 将指定 requestId 移出公平锁排队队列，后续请求无需等待其超时即可前移。
+// 若该 requestId 当前正持有锁，则返回 ErrFairCancelFailed，应使用 FairUnLock 释放。
+func (l *RedisLock) FairCancel(ctx context.Context, requestId string) error {
+	res, err := l.eval(
 		ctx,
-		fairRenewScript,
+		fairCancelScript,
 		[]string{l.key},
 		requestId,
-		l.lockTimeout.Milliseconds(),
 	).Int64()
 
 	if err != nil {
-		return errors.Join(err, ErrException)
+		return exceptionErr(err)
 	}
 
-	if res != 1 {
-		return ErrLockRenewFailed
+	if res != codeOK {
+		return ErrFairCancelFailed
 	}
 
 	return nil
 }
 
-// 锁自动续期
-func (l *RedisLock) autoFairRenew(ctx context.Context, requestId string) {
-	ticker := time.NewTicker(l.lockTimeout / 3)
-	defer ticker.Stop()
+// 自旋放弃时离开排队队列
+// 调用方的 ctx 此时可能已取消或超时，因此使用独立的短超时 ctx 执行清理
+func (l *RedisLock) leaveFairQueue(ctx context.Context, requestId string) {
+	ctxCancel, cancel := context.WithTimeout(context.WithoutCancel(ctx), fairCancelTimeout)
+	defer cancel()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			err := l.FairRenew(ctx, requestId)
-			if err != nil {
-				log.Printf("Error: autoFairRenew failed, Err: %v \n", err)
-				return
-			}
-		}
+	if err := l.FairCancel(ctxCancel, requestId); err != nil {
+		l.logf("Error: leave fair queue failed, Err: %v \n", err)
 	}
 }
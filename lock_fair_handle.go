@@ -0,0 +1,58 @@
+package go_redislock
+
+import (
+	"context"
+	"time"
+)
+
+// RedisFairLock 公平锁句柄，requestId 由句柄自动生成并在加锁、续期、解锁之间保持一致
+type RedisFairLock struct {
+	lock *RedisLock
+}
+
+// NewFair creates a fair lock handle whose requestId is generated internally.
+// The requestId follows the same scheme as the lock token of New, and can be overridden with WithToken.
+//
+// NewFair 创建公平锁句柄，无需调用方自行生成和传递 requestId。
+// requestId 与 New 生成锁 Token 的方式一致，也可通过 WithToken 指定（例如跨进程交接锁的场景）。
+func NewFair(redisClient RedisInter, lockKey string, options ...Option) RedisFairLockInter {
+	return &RedisFairLock{
+		lock: newRedisLock(redisClient, lockKey, options...),
+	}
+}
+
+// Lock tries to acquire the fair lock.
+// Lock 尝试获取公平锁
+func (f *RedisFairLock) Lock(ctx context.Context) error {
+	return f.lock.FairLock(ctx, f.lock.token)
+}
+
+// SpinLock keeps trying to acquire the fair lock until timeout.
+// SpinLock 在指定超时时间内不断尝试获取公平锁
+func (f *RedisFairLock) SpinLock(ctx context.Context, timeout time.Duration) error {
+	return f.lock.SpinFairLock(ctx, f.lock.token, timeout)
+}
+
+// UnLock releases the fair lock.
+// UnLock 释放公平锁
+func (f *RedisFairLock) UnLock(ctx context.Context) error {
+	return f.lock.FairUnLock(ctx, f.lock.token)
+}
+
+// Renew manually extends the fair lock expiration.
+// Renew 手动延长公平锁有效期
+func (f *RedisFairLock) Renew(ctx context.Context) error {
+	return f.lock.FairRenew(ctx, f.lock.token)
+}
+
+// Cancel leaves the fair lock queue.
+// Cancel 离开公平锁排队队列
+func (f *RedisFairLock) Cancel(ctx context.Context) error {
+	return f.lock.FairCancel(ctx, f.lock.token)
+}
+
+// RequestId returns the requestId used by the handle.
+// RequestId 返回句柄使用的 requestId
+func (f *RedisFairLock) RequestId() string {
+	return f.lock.token
+}
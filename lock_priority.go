@@ -0,0 +1,71 @@
+package go_redislock
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+)
+
+var (
+	//go:embed lua/priorityLock.lua
+	priorityLockScript string
+)
+
+// PriorityFairLock tries to acquire a priority fair lock using the given requestId.
+// Requests with a higher priority are served first, FIFO order holds within the same priority.
+// Use FairUnLock / FairRenew / FairCancel with the same requestId to release, renew or leave the queue.
+//
+// PriorityFairLock 优先级公平锁尝试加锁。
+// 优先级越高越先获取锁，同一优先级内按到达顺序（FIFO）获取锁。
+// 解锁、续期、取消排队使用同一 requestId 调用 FairUnLock / FairRenew / FairCancel。
+// 同一个 key 请勿与 FairLock 混用。
+func (l *RedisLock) PriorityFairLock(ctx context.Context, requestId string, priority int) error {
+	if priority < MinPriority || priority > MaxPriority {
+		return ErrInvalidPriority
+	}
+
+	cmd, replicaErr := l.evalReplicated(ctx, priorityLockScript,
+		[]string{l.key},
+		requestId,
+		l.lockTimeout.Milliseconds(),
+		l.requestTimeout.Milliseconds(),
+		l.maxQueueLength,
+		priority,
+		l.priorityAging.Milliseconds(),
+	)
+	result, err := cmd.Result()
+
+	if err != nil {
+		return exceptionErr(err)
+	}
+
+	// 没有抢到锁，则进入排队，不是ok则说明不是队首
+	if err = l.lockErr(KindPriority, result); err != nil {
+		return err
+	}
+	if replicaErr != nil {
+		l.rollback(ctx, fairUnLockScript, requestId)
+		return fmt.Errorf("%w: %w", ErrLockFailed, replicaErr)
+	}
+
+	if l.isAutoRenew {
+		l.startAutoRenew(ctx, KindFair, requestId, "autoFairRenew", func(ctx context.Context) error {
+			return l.FairRenew(ctx, requestId)
+		})
+	}
+
+	return nil
+}
+
+// SpinPriorityFairLock keeps trying to acquire a priority fair lock until timeout.
+// SpinPriorityFairLock 在指定超时时间内不断尝试获取优先级公平锁。
+func (l *RedisLock) SpinPriorityFairLock(ctx context.Context, requestId string, priority int, timeout time.Duration) error {
+	if priority < MinPriority || priority > MaxPriority {
+		return ErrInvalidPriority
+	}
+
+	return l.spinFair(ctx, requestId, timeout, func() error {
+		return l.PriorityFairLock(ctx, requestId, priority)
+	})
+}
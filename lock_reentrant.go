@@ -4,7 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"errors"
-	"log"
+	"fmt"
 	"time"
 )
 
@@ -23,23 +23,34 @@ var (
 // Lock 尝试获取普通锁。
 // 该实现支持“可重入锁”，如果当前已由相同 key+token 持有，允许重入并增加计数。需调用相应次数 Unlock() 释放
 func (l *RedisLock) Lock(ctx context.Context) error {
-	result, err := l.redis.Eval(ctx, reentrantLockScript,
+	// 降级期间其他持有者获得的进程内锁尚未释放
+	if l.localHeldByOthers() {
+		return codeErr(ErrLockFailed, codeLockHeld)
+	}
+
+	cmd, replicaErr := l.evalReplicated(ctx, reentrantLockScript,
 		[]string{l.key},
 		l.token,
 		l.lockTimeout.Milliseconds(),
-	).Int64()
+	)
+	result, err := cmd.Result()
 
 	if err != nil {
-		return errors.Join(err, ErrException)
+		if ok, localErr := l.localFallback(err); ok {
+			return localErr
+		}
+		return exceptionErr(err)
 	}
-	if result != 1 {
-		return ErrLockFailed
+	if err = l.lockErr(KindReentrant, result); err != nil {
+		return err
+	}
+	if replicaErr != nil {
+		l.rollback(ctx, reentrantUnLockScript, l.token)
+		return fmt.Errorf("%w: %w", ErrLockFailed, replicaErr)
 	}
 
 	if l.isAutoRenew {
-		ctxRenew, cancel := context.WithCancel(ctx)
-		l.autoRenewCancel = cancel
-		go l.autoRenew(ctxRenew)
+		l.startAutoRenew(ctx, KindReentrant, l.token, "autoRenew", l.Renew)
 	}
 
 	return nil
@@ -56,20 +67,12 @@ func (l *RedisLock) UnLock(ctx context.Context) error {
 		l.autoRenewCancel()
 	}
 
-	result, err := l.redis.Eval(
-		ctx,
-		reentrantUnLockScript,
-		[]string{l.key}, l.token,
-	).Int64()
-
-	if err != nil {
-		return errors.Join(err, ErrException)
-	}
-	if result != 1 {
-		return ErrUnLockFailed
+	if l.localUnLock() {
+		return nil
 	}
 
-	return nil
+	releaseId, recordTTL := l.releaseRecord()
+	return l.release(ctx, reentrantUnLockScript, l.token, releaseId, recordTTL)
 }
 
 // SpinLock keeps trying to acquire the lock until timeout.
@@ -99,40 +102,35 @@ func (l *RedisLock) SpinLock(ctx context.Context, timeout time.Duration) error {
 // Renew manually extends the lock expiration.
 // Renew 手动延长锁的有效期。
 func (l *RedisLock) Renew(ctx context.Context) error {
-	res, err := l.redis.Eval(
-		ctx,
-		reentrantRenewScript,
-		[]string{l.key},
-		l.token,
-		l.lockTimeout.Milliseconds(),
-	).Int64()
+	// 进程内锁没有有效期，无需续期
+	if l.localHeld() {
+		return nil
+	}
+
+	var replicaErr error
+	cmd := l.retry(ctx, func() RedisCmd {
+		var cmd RedisCmd
+		cmd, replicaErr = l.evalReplicated(
+			ctx,
+			reentrantRenewScript,
+			[]string{l.key},
+			l.token,
+			l.renewLease().Milliseconds(),
+		)
+		return cmd
+	})
+	res, err := cmd.Int64()
 
 	if err != nil {
-		return errors.Join(err, ErrException)
+		return exceptionErr(err)
 	}
 
-	if res != 1 {
-		return ErrLockRenewFailed
+	if res != codeOK {
+		return codeErr(ErrLockRenewFailed, res)
+	}
+	if replicaErr != nil {
+		return fmt.Errorf("%w: %w", ErrLockRenewFailed, replicaErr)
 	}
 
 	return nil
 }
-
-// 锁自动续期
-func (l *RedisLock) autoRenew(ctx context.Context) {
-	ticker := time.NewTicker(l.lockTimeout / 3)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			err := l.Renew(ctx)
-			if err != nil {
-				log.Printf("Error: autoRenew failed, %v", err)
-				return
-			}
-		}
-	}
-}
@@ -4,7 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"errors"
-	"log"
+	"fmt"
 	"time"
 )
 
@@ -18,24 +18,27 @@ var (
 )
 
 func (l *RedisLock) WLock(ctx context.Context) error {
-	res, err := l.redis.Eval(ctx, writeLockScript,
+	cmd, replicaErr := l.evalReplicated(ctx, writeLockScript,
 		[]string{l.key},
 		l.token,
 		l.lockTimeout.Milliseconds(),
-	).Int64()
+	)
+	res, err := cmd.Result()
 
 	if err != nil {
-		return errors.Join(err, ErrException)
+		return exceptionErr(err)
 	}
 
-	if res != 1 {
-		return ErrLockFailed
+	if err = l.lockErr(KindWrite, res); err != nil {
+		return err
+	}
+	if replicaErr != nil {
+		l.rollback(ctx, writeUnLockScript, l.token)
+		return fmt.Errorf("%w: %w", ErrLockFailed, replicaErr)
 	}
 
 	if l.isAutoRenew {
-		ctxRenew, cancel := context.WithCancel(ctx)
-		l.autoRenewCancel = cancel
-		go l.autoWLockRenew(ctxRenew)
+		l.startAutoRenew(ctx, KindWrite, l.token, "autoWRenew", l.WRenew)
 	}
 
 	return nil
@@ -47,20 +50,8 @@ func (l *RedisLock) WUnLock(ctx context.Context) error {
 		l.autoRenewCancel()
 	}
 
-	res, err := l.redis.Eval(
-		ctx,
-		writeUnLockScript,
-		[]string{l.key}, l.token,
-	).Int64()
-
-	if err != nil {
-		return errors.Join(err, ErrException)
-	}
-	if res != 1 {
-		return ErrUnLockFailed
-	}
-
-	return nil
+	releaseId, recordTTL := l.releaseRecord()
+	return l.release(ctx, writeUnLockScript, l.token, releaseId, recordTTL)
 }
 
 func (l *RedisLock) SpinWLock(ctx context.Context, timeout time.Duration) error {
@@ -86,40 +77,30 @@ func (l *RedisLock) SpinWLock(ctx context.Context, timeout time.Duration) error
 }
 
 func (l *RedisLock) WRenew(ctx context.Context) error {
-	res, err := l.redis.Eval(
-		ctx,
-		writeRenewScript,
-		[]string{l.key},
-		l.token,
-		l.lockTimeout.Milliseconds(),
-	).Int64()
+	var replicaErr error
+	cmd := l.retry(ctx, func() RedisCmd {
+		var cmd RedisCmd
+		cmd, replicaErr = l.evalReplicated(
+			ctx,
+			writeRenewScript,
+			[]string{l.key},
+			l.token,
+			l.renewLease().Milliseconds(),
+		)
+		return cmd
+	})
+	res, err := cmd.Int64()
 
 	if err != nil {
-		return errors.Join(err, ErrException)
+		return exceptionErr(err)
 	}
 
-	if res != 1 {
-		return ErrLockRenewFailed
+	if res != codeOK {
+		return codeErr(ErrLockRenewFailed, res)
+	}
+	if replicaErr != nil {
+		return fmt.Errorf("%w: %w", ErrLockRenewFailed, replicaErr)
 	}
 
 	return nil
 }
-
-// 锁自动续期
-func (l *RedisLock) autoWLockRenew(ctx context.Context) {
-	ticker := time.NewTicker(l.lockTimeout / 3)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			err := l.WRenew(ctx)
-			if err != nil {
-				log.Printf("Error: autoWRenew failed, %v", err)
-				return
-			}
-		}
-	}
-}
@@ -0,0 +1,98 @@
+--[[
+    Batch Renew Script (批量续期脚本)
+
+    功能描述：
+    一次调用为多把锁续期，供共享续期调度器使用，避免每把锁单独往返一次 Redis。
+    每把锁的续期逻辑与对应的单锁续期脚本（reentrantRenew、fairRenew、readRenew、writeRenew）一致，修改时需同步修改。
+
+    输入参数：
+    KEYS[i]     - 第 i 把锁的 key
+    ARGV[3i-2]  - 第 i 把锁的类型：reentrant、fair、read、write
+    ARGV[3i-1]  - 第 i 把锁的持有者标识（Token 或 requestId）
+    ARGV[3i]    - 第 i 把锁续期的 TTL（毫秒）
+
+    返回值：
+    与 KEYS 一一对应的返回码数组，返回码与单锁续期脚本一致：
+    1  续期成功
+    -4 锁模式不匹配（写锁续期时当前为读锁模式）
+    -5 不是锁的持有者
+    -6 锁不存在或已过期
+--]]
+
+-- 普通锁（可重入）
+local function renew_reentrant(key, lock_value, lock_ttl)
+    local lock_key = '{' .. key .. '}'
+    local reentrant_key = lock_key .. ':count:' .. lock_value
+    local reentrant_count = tonumber(redis.call('GET', reentrant_key) or '0')
+    local holder = redis.call('GET', lock_key)
+    if reentrant_count > 0 or holder == lock_value then
+        redis.call('PEXPIRE', lock_key, lock_ttl)
+        redis.call('PEXPIRE', reentrant_key, lock_ttl)
+        return 1
+    end
+    if not holder then
+        return -6
+    end
+    return -5
+end
+
+-- 公平锁
+local function renew_fair(key, request_id, lock_ttl)
+    local lock_key = '{' .. key .. '}'
+    local holder = redis.call('GET', lock_key)
+    if holder == request_id then
+        redis.call('PEXPIRE', lock_key, lock_ttl)
+        return 1
+    end
+    if not holder then
+        return -6
+    end
+    return -5
+end
+
+-- 读锁
+local function renew_read(key, lock_value, lock_ttl)
+    local self_cnt = tonumber(redis.call('HGET', key, 'r:' .. lock_value) or '0')
+    if self_cnt <= 0 then
+        if redis.call('EXISTS', key) == 0 then
+            return -6
+        end
+        return -5
+    end
+    redis.call('PEXPIRE', key, lock_ttl)
+    return 1
+end
+
+-- 写锁
+local function renew_write(key, lock_value, lock_ttl)
+    local mode = redis.call('HGET', key, 'mode')
+    if not mode then
+        return -6
+    end
+    if mode ~= 'write' then
+        return -4
+    end
+    if redis.call('HGET', key, 'writer') ~= lock_value then
+        return -5
+    end
+    redis.call('PEXPIRE', key, lock_ttl)
+    return 1
+end
+
+local renewers = {
+    reentrant = renew_reentrant,
+    fair = renew_fair,
+    read = renew_read,
+    write = renew_write,
+}
+
+local result = {}
+for i, key in ipairs(KEYS) do
+    local kind = ARGV[3 * i - 2]
+    local renew = renewers[kind]
+    if not renew then
+        return redis.error_reply('unknown lock kind: ' .. tostring(kind))
+    end
+    result[i] = renew(key, ARGV[3 * i - 1], tonumber(ARGV[3 * i]) or 0)
+end
+return result
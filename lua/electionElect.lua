@@ -0,0 +1,50 @@
+--[[
+    Leader Election Term Script (选主任期脚本)
+
+    功能描述：
+    候选者通过可重入锁脚本获得锁后调用本脚本成为 leader：
+    递增任期（term），记录 leader 的标识与元数据，并通知观察者。
+
+    输入参数：
+    KEYS[1]     - 选举的 key（与锁的 key 相同）
+    ARGV[1]     - 候选者标识（锁的 Token）
+    ARGV[2]     - 候选者元数据
+
+    Redis 数据结构：
+    1. 主锁 key:
+        格式：{KEYS[1]}
+        值：leader 标识（由可重入锁脚本写入）
+    2. leader 信息 key（hash，不设置过期时间，保证任期单调递增）:
+        格式：{KEYS[1]}:leader
+        字段：term 任期、id leader 标识、meta leader 元数据
+    3. 通知频道：
+        格式：{KEYS[1]}:leader
+
+    执行逻辑：
+    1. 校验当前候选者仍持有锁；
+    2. 任期加 1，写入 leader 标识与元数据；
+    3. 向通知频道发布新任期，返回新任期。
+
+    返回值：
+    - 大于 0：新任期，可作为 fencing token
+    - -5：非本候选者持有锁
+    - -6：锁不存在或已过期
+--]]
+
+local lock_key = '{' .. KEYS[1] .. '}'
+local leader_key = lock_key .. ':leader'
+local candidate = ARGV[1]
+local metadata = ARGV[2]
+
+local holder = redis.call('GET', lock_key)
+if not holder then
+    return -6
+end
+if holder ~= candidate then
+    return -5
+end
+
+local term = redis.call('HINCRBY', leader_key, 'term', 1)
+redis.call('HSET', leader_key, 'id', candidate, 'meta', metadata)
+redis.call('PUBLISH', leader_key, term)
+return term
@@ -0,0 +1,41 @@
+--[[
+    Leader Query Script (查询 leader 脚本)
+
+    功能描述：
+    查询当前 leader 的标识、元数据与任期。
+
+    输入参数：
+    KEYS[1]     - 选举的 key（与锁的 key 相同）
+
+    Redis 数据结构：
+    1. 主锁 key：{KEYS[1]}，值为 leader 标识
+    2. leader 信息 key：{KEYS[1]}:leader，字段 term、id、meta
+
+    执行逻辑：
+    1. 锁不存在时没有 leader；
+    2. 锁持有者与 leader 信息一致时返回完整信息；
+    3. 不一致时（持有者获得锁后尚未递增任期）只返回持有者，元数据为空、任期为 0。
+
+    返回值：
+    - {0}：没有 leader
+    - {1, pttl, id, meta, term}：当前 leader，pttl 为锁的剩余有效期（毫秒）
+--]]
+
+local lock_key = '{' .. KEYS[1] .. '}'
+local leader_key = lock_key .. ':leader'
+
+local holder = redis.call('GET', lock_key)
+if not holder then
+    return {0}
+end
+
+local pttl = redis.call('PTTL', lock_key)
+if redis.call('HGET', leader_key, 'id') ~= holder then
+    return {1, pttl, holder, '', 0}
+end
+
+return {
+    1, pttl, holder,
+    redis.call('HGET', leader_key, 'meta') or '',
+    tonumber(redis.call('HGET', leader_key, 'term') or '0')
+}
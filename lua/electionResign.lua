@@ -0,0 +1,34 @@
+--[[
+    Leader Resign Script (leader 退位脚本)
+
+    功能描述：
+    leader 通过可重入锁脚本释放锁后调用本脚本：清除 leader 标识与元数据（保留任期），并通知观察者与其他候选者。
+
+    输入参数：
+    KEYS[1]     - 选举的 key（与锁的 key 相同）
+    ARGV[1]     - leader 标识（锁的 Token）
+
+    Redis 数据结构：
+    1. leader 信息 key：{KEYS[1]}:leader，字段 term、id、meta
+    2. 通知频道：{KEYS[1]}:leader
+
+    执行逻辑：
+    1. leader 信息中的标识不是当前调用者时（已有新 leader），不做修改；
+    2. 否则删除 id、meta 字段，任期保持不变；
+    3. 向通知频道发布消息。
+
+    返回值：
+    - 1：已清除
+    - -5：leader 已是其他候选者
+--]]
+
+local leader_key = '{' .. KEYS[1] .. '}:leader'
+local candidate = ARGV[1]
+
+if redis.call('HGET', leader_key, 'id') ~= candidate then
+    return -5
+end
+
+redis.call('HDEL', leader_key, 'id', 'meta')
+redis.call('PUBLISH', leader_key, 0)
+return 1
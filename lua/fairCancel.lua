@@ -0,0 +1,41 @@
+--[[
+    Fair Queue Cancel Script (公平锁排队取消脚本)
+
+    功能描述：
+    将指定请求 ID 从公平锁的排队队列中移除，使排在其后的请求无需等待其超时即可前移。
+    用于自旋获取公平锁超时、上下文取消等放弃排队的场景。
+
+    输入参数：
+    KEYS[1]      - 业务锁 key（如 "my-lock"）
+    ARGV[1]      - 请求 ID（与加锁时传入的一致）
+
+    Redis 数据结构说明：
+    1. 主锁键（{KEYS[1]}）：存储当前持锁请求 ID；
+    2. 排队键（{KEYS[1]}:queue）：ZSET，记录所有等待请求，score 为时间戳；
+    3. 排队时间键（{KEYS[1]}:queue:ts）：ZSET，优先级公平锁记录入队时间，不存在时忽略。
+
+    执行逻辑：
+    1. 若当前请求已持有锁，不做任何处理，返回 0（持锁者应使用解锁脚本释放）；
+    2. 否则从排队队列中移除该请求 ID，返回 1。
+
+    返回值：
+    - 1：已离开队列（或本就不在队列中，幂等）
+    - 0：当前请求正持有锁，未取消
+--]]
+
+
+local lock_key = '{' .. KEYS[1] .. '}'
+local queue_key = lock_key .. ':queue'
+local queue_ts_key = queue_key .. ':ts'
+local request_id = ARGV[1]
+
+-- 持锁者不允许取消，需走解锁流程
+if redis.call('GET', lock_key) == request_id then
+    return 0
+end
+
+-- 离开排队队列
+redis.call('ZREM', queue_key, request_id)
+redis.call('ZREM', queue_ts_key, request_id) -- 优先级公平锁的入队时间
+
+return 1
@@ -16,6 +16,7 @@
     ARGV[1]      - 请求 ID（一般为客户端 ID + 唯一请求标识，如 UUID）
     ARGV[2]      - 锁的过期时间（毫秒，lock_ttl）
     ARGV[3]      - 请求最大等待时间（毫秒，request_timeout）
+    ARGV[4]      - 队列最大长度（max_queue_length，0 表示不限制，包含当前持锁请求）
 
     Redis 数据结构说明：
     1. 锁 key:     Redis String，存储当前持有锁的请求 ID
@@ -24,16 +25,20 @@
     执行流程：
     1. 获取当前时间戳 current_time；
     2. 清理 queue_key 中所有超过 request_timeout 的请求（ZREMRANGEBYSCORE）；
-    3. 将当前请求 ID 按当前时间戳添加到 ZSET 队列中（ZADD）；
+    3. 若当前请求尚未排队且队列长度已达上限，拒绝入队并返回 -1；
+       否则将当前请求 ID 按当前时间戳添加到 ZSET 队列中（ZADD）；
     4. 设置 queue_key 过期时间为 request_timeout（用于自动过期清理）；
     5. 检查当前请求是否是队首（ZRANGE 0 0）：
         - 是，则尝试使用 SET NX EX 获取锁；
         - 如果成功，加锁成功，返回 1；
-        - 否则或不是队首，则返回 0。
+        - 锁被占用返回 -2，不是队首返回 -3。
 
     返回值：
     - 1：加锁成功（当前请求是队首且成功获取锁）
-    - 0 ：加锁失败（未轮到或抢锁失败）
+    - 失败时返回 {code, pttl, holder}，附带锁剩余有效期（毫秒）与当前持有者（锁空闲时无 holder）：
+      code = -1：队列已满，拒绝入队
+      code = -2：加锁失败（是队首但锁仍被占用）
+      code = -3：加锁失败（不是队首，继续排队）
 
     建议使用说明（客户端逻辑）：
     - 客户端加锁失败应设置间隔轮询重试；
@@ -54,6 +59,16 @@ local queue_key = lock_key .. ':queue'
 local request_id = ARGV[1]
 local lock_ttl = tonumber(ARGV[2])
 local request_timeout = tonumber(ARGV[3])
+local max_queue_length = tonumber(ARGV[4]) or 0
+
+-- 加锁失败：返回原因、锁剩余有效期与当前持有者
+local function fail(code)
+    local holder = redis.call('GET', lock_key)
+    if holder then
+        return {code, redis.call('PTTL', lock_key), holder}
+    end
+    return {code, -2}
+end
 
 -- 当前毫秒数
 local current_time = tonumber(redis.call('TIME')[1])
@@ -62,6 +77,13 @@ local current_time_ms  = current_time * 1000
 -- 清理超时的请求
 redis.call('ZREMRANGEBYSCORE', queue_key, 0, current_time_ms - request_timeout)
 
+-- 准入控制：新请求在队列已满时直接拒绝，已在队列中的请求不受影响
+if max_queue_length > 0 and not redis.call('ZSCORE', queue_key, request_id) then
+    if redis.call('ZCARD', queue_key) >= max_queue_length then
+        return fail(-1) -- 队列已满
+    end
+end
+
 -- 加锁（排队）
 -- 将请求 ID 添加到队列中，并设置过期时间
 redis.call('ZADD', queue_key, 'NX', current_time_ms, request_id)
@@ -69,7 +91,7 @@ redis.call('PEXPIRE', queue_key, request_timeout)
 
 -- 判断自己是否在队首
 if redis.call('ZRANK', queue_key, request_id) ~= 0 then
-    return 0 -- 还没轮到我
+    return fail(-3) -- 还没轮到我
 end
 
 -- 只有队首才尝试抢锁
@@ -77,4 +99,4 @@ if redis.call('SET', lock_key, request_id, 'NX', 'PX', lock_ttl) then
     return 1
 end
 
-return 0 -- 锁被别人占着
+return fail(-2) -- 锁被别人占着
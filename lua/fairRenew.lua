@@ -10,7 +10,8 @@
 
     返回：
       1  续期成功（确实持有该锁并已刷新 TTL）
-      0  续期失败（锁不存在，或锁已被其他请求持有）
+     -5  续期失败（锁已被其他请求持有）
+     -6  续期失败（锁不存在或已过期）
 --]]
 
 
@@ -19,9 +20,14 @@ local request_id    = ARGV[1]
 local lock_ttl  = tonumber(ARGV[2])
 
 -- 只允许当前持锁者续期
-if redis.call('GET', lock_key) == request_id then
+local holder = redis.call('GET', lock_key)
+if holder == request_id then
     redis.call('PEXPIRE', lock_key, lock_ttl)
     return 1 -- 续期成功
 end
 
-return 0  -- 续期失败：要么锁不存在，要么不是你的
\ No newline at end of file
+if not holder then
+    return -6 -- 续期失败：锁不存在或已过期
+end
+
+return -5 -- 续期失败：锁不是你的
\ No newline at end of file
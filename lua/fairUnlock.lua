@@ -14,15 +14,16 @@
 
     Redis 数据结构说明：
     1. 主锁键（{KEYS[1]}）：存储当前持锁请求 ID；
-    2. 排队键（{KEYS[1]}:queue）：ZSET，记录所有等待请求，score 为时间戳。
+    2. 排队键（{KEYS[1]}:queue）：ZSET，记录所有等待请求，score 为时间戳；
+    3. 排队时间键（{KEYS[1]}:queue:ts）：ZSET，优先级公平锁记录入队时间，不存在时忽略。
 
     执行逻辑：
     1. 若当前请求 ID 与锁键中的值一致（是锁的持有者），则删除锁键；
     2. 无论是否持有锁，统一从 ZSET 排队队列中移除该请求 ID；
-    3. 返回 "OK" 表示执行成功。
+    3. 返回 1 表示执行成功。
 
     返回值：
-    - "OK"：无论是否实际持有锁，解锁请求都被成功处理（幂等）
+    - 1：无论是否实际持有锁，解锁请求都被成功处理（幂等），不返回非持有者或已过期的错误码
 
     注意事项：
     - 使用 `GET lock_key == request_id` 判断是否是锁的持有者；
@@ -35,6 +36,7 @@
 
 local lock_key = '{' .. KEYS[1] .. '}'
 local queue_key = lock_key .. ':queue'
+local queue_ts_key = queue_key .. ':ts'
 local request_id = ARGV[1]
 
 -- 删除锁键（只删除自己持有的锁）
@@ -44,5 +46,6 @@ end
 
 -- 从队列中删除请求ID
 redis.call('ZREM', queue_key, request_id)
+redis.call('ZREM', queue_ts_key, request_id) -- 优先级公平锁的入队时间
 
 return 1
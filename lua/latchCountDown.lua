@@ -0,0 +1,39 @@
+--[[
+    CountDownLatch Count Down Script (CountDownLatch 计数减一脚本)
+
+    功能描述：
+    计数减 1 并刷新有效期；计数归零时保留计数为 0 的 latch 作为完成标记，并通知等待者。
+
+    输入参数：
+    KEYS[1]     - latch 的 key
+    ARGV[1]     - latch 的有效期（单位：毫秒）
+
+    Redis 数据结构：
+    1. latch key:
+        格式：{KEYS[1]}:latch
+        值：剩余计数，0 表示已归零（完成标记，latch_ttl 后过期）
+    2. 通知频道：
+        格式：{KEYS[1]}:latch
+        计数归零时 PUBLISH
+
+    执行逻辑：
+    1. latch 不存在（未设置或已过期）或已归零时不做修改，返回 0；
+    2. 计数减 1 并刷新有效期，返回剩余计数；
+    3. 归零时向通知频道发布消息。
+
+    返回值：
+    - 剩余计数
+--]]
+
+local latch_key = '{' .. KEYS[1] .. '}:latch'
+
+if tonumber(redis.call('GET', latch_key) or '0') <= 0 then
+    return 0
+end
+
+local count = redis.call('DECR', latch_key)
+redis.call('PEXPIRE', latch_key, tonumber(ARGV[1]))
+if count == 0 then
+    redis.call('PUBLISH', latch_key, 0)
+end
+return count
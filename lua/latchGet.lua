@@ -0,0 +1,19 @@
+--[[
+    CountDownLatch Get Count Script (CountDownLatch 查询计数脚本)
+
+    功能描述：
+    查询 latch 的剩余计数与剩余有效期。
+
+    输入参数：
+    KEYS[1]     - latch 的 key
+
+    Redis 数据结构：
+    1. latch key：{KEYS[1]}:latch，值为剩余计数，0 表示已归零
+
+    返回值：
+    - {count, pttl}：剩余计数与剩余有效期（毫秒），latch 不存在时为 {0, -2}
+--]]
+
+local latch_key = '{' .. KEYS[1] .. '}:latch'
+
+return {tonumber(redis.call('GET', latch_key) or '0'), redis.call('PTTL', latch_key)}
@@ -0,0 +1,31 @@
+--[[
+    CountDownLatch Set Count Script (CountDownLatch 设置计数脚本)
+
+    功能描述：
+    在 latch 不存在（未设置或已过期）或已归零时设置计数。
+
+    输入参数：
+    KEYS[1]     - latch 的 key
+    ARGV[1]     - 计数（大于 0）
+    ARGV[2]     - latch 的有效期（单位：毫秒）
+
+    Redis 数据结构：
+    1. latch key:
+        格式：{KEYS[1]}:latch
+        值：剩余计数，0 表示已归零
+        设置：SET PX latch_ttl
+
+    返回值：
+    - 1：设置成功
+    - 0：latch 计数大于 0，未修改
+--]]
+
+local latch_key = '{' .. KEYS[1] .. '}:latch'
+
+-- 计数大于 0 时 latch 仍在使用，不修改
+if tonumber(redis.call('GET', latch_key) or '0') > 0 then
+    return 0
+end
+
+redis.call('SET', latch_key, ARGV[1], 'PX', tonumber(ARGV[2]))
+return 1
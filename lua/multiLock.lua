@@ -8,5 +8,5 @@ if redis.call('SET', lock_key, lock_value, 'NX', 'PX', lock_ttl) then
     return 1
 end
 
--- 获取失败
-return 0
+-- 获取失败（锁被占用）
+return -2
@@ -0,0 +1,101 @@
+--[[
+    Priority Fair Queue Distributed Lock Using ZSET (基于有序集合的优先级公平分布式锁脚本)
+
+    功能描述：
+    在公平锁排队机制的基础上支持请求优先级：优先级高的请求先获取锁，同一优先级内保持 FIFO。
+    可选开启“老化”（aging）：低优先级请求每等待一个老化周期，相当于优先级提升一级，避免长期饥饿。
+
+    输入参数：
+    KEYS[1]      - 锁的 key（如 "resource-lock"）
+    ARGV[1]      - 请求 ID
+    ARGV[2]      - 锁的过期时间（毫秒，lock_ttl）
+    ARGV[3]      - 请求最大等待时间（毫秒，request_timeout）
+    ARGV[4]      - 队列最大长度（max_queue_length，0 表示不限制）
+    ARGV[5]      - 请求优先级（priority，数值越大越优先）
+    ARGV[6]      - 老化周期（毫秒，aging，0 表示严格按优先级）
+
+    Redis 数据结构说明：
+    1. 锁 key:          Redis String，存储当前持有锁的请求 ID（与公平锁一致）
+    2. 排队 key:        ZSET，score 为排序分值，value 为请求 ID（与公平锁一致）
+    3. 排队时间 key:    ZSET，score 为入队时间戳，用于清理超时请求
+
+    排序分值：
+    score = 入队时间戳 - priority * step
+    - 开启老化时 step 为老化周期：优先级高一级等价于提前 step 毫秒入队；
+    - 未开启老化时 step 取一个足够大的值（1e12 毫秒），保证严格按优先级排序；
+    - 同一优先级的请求 score 只取决于入队时间，保持 FIFO。
+
+    返回值：
+    - 1：加锁成功（当前请求是队首且成功获取锁）
+    - 失败时返回 {code, pttl, holder}，附带锁剩余有效期（毫秒）与当前持有者（锁空闲时无 holder）：
+      code = -1：队列已满，拒绝入队
+      code = -2：加锁失败（是队首但锁仍被占用）
+      code = -3：加锁失败（不是队首，继续排队）
+
+    注意事项：
+    - 排队分值不再等于入队时间，同一个 key 不要与普通公平锁（fairLock）混用；
+    - 解锁、续期、取消排队复用公平锁脚本。
+--]]
+
+
+local lock_key = '{' .. KEYS[1] .. '}'
+local queue_key = lock_key .. ':queue'
+local queue_ts_key = queue_key .. ':ts'
+local request_id = ARGV[1]
+local lock_ttl = tonumber(ARGV[2])
+local request_timeout = tonumber(ARGV[3])
+local max_queue_length = tonumber(ARGV[4]) or 0
+local priority = tonumber(ARGV[5]) or 0
+local aging = tonumber(ARGV[6]) or 0
+
+-- 加锁失败：返回原因、锁剩余有效期与当前持有者
+local function fail(code)
+    local holder = redis.call('GET', lock_key)
+    if holder then
+        return {code, redis.call('PTTL', lock_key), holder}
+    end
+    return {code, -2}
+end
+
+-- 当前毫秒数（优先级老化需要毫秒精度）
+local now = redis.call('TIME')
+local current_time_ms = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+
+-- 清理超时的请求（按入队时间）
+local expired = redis.call('ZRANGEBYSCORE', queue_ts_key, 0, current_time_ms - request_timeout)
+for _, expired_id in ipairs(expired) do
+    redis.call('ZREM', queue_key, expired_id)
+end
+redis.call('ZREMRANGEBYSCORE', queue_ts_key, 0, current_time_ms - request_timeout)
+
+-- 准入控制：新请求在队列已满时直接拒绝，已在队列中的请求不受影响
+if max_queue_length > 0 and not redis.call('ZSCORE', queue_key, request_id) then
+    if redis.call('ZCARD', queue_key) >= max_queue_length then
+        return fail(-1) -- 队列已满
+    end
+end
+
+-- 计算排序分值
+local step = 1e12
+if aging > 0 then
+    step = aging
+end
+local score = current_time_ms - priority * step
+
+-- 加锁（排队）
+redis.call('ZADD', queue_key, 'NX', score, request_id)
+redis.call('ZADD', queue_ts_key, 'NX', current_time_ms, request_id)
+redis.call('PEXPIRE', queue_key, request_timeout)
+redis.call('PEXPIRE', queue_ts_key, request_timeout)
+
+-- 判断自己是否在队首
+if redis.call('ZRANK', queue_key, request_id) ~= 0 then
+    return fail(-3) -- 还没轮到我
+end
+
+-- 只有队首才尝试抢锁
+if redis.call('SET', lock_key, request_id, 'NX', 'PX', lock_ttl) then
+    return 1
+end
+
+return fail(-2) -- 锁被别人占着
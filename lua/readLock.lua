@@ -53,5 +53,5 @@ if mode == 'write' then
 end
 
 -- 如果锁是写锁且持有者不是自己，则无法获取读锁
--- 他人持有写锁：失败
-return 0
\ No newline at end of file
+-- 他人持有写锁：失败（锁模式不匹配），返回剩余有效期与写锁持有者
+return {-4, redis.call('PTTL', local_key), redis.call('HGET', local_key, 'writer')}
\ No newline at end of file
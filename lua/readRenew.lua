@@ -7,7 +7,10 @@ local self_cnt = tonumber(redis.call('HGET', local_key, 'r:' .. lock_value) or '
 
 -- 如果当前线程没有持有读锁，则续期失败
 if self_cnt <= 0 then
-    return 0
+    if redis.call('EXISTS', local_key) == 0 then
+        return -6 -- 锁已过期
+    end
+    return -5 -- 不是读锁持有者
 end
 
 -- 刷新锁的 TTL，延长锁有效期，避免锁过期被其他线程抢占
@@ -1,12 +1,35 @@
 local local_key = KEYS[1]
 local lock_value = ARGV[1] -- 当前请求解锁的持有者标识（owner）
+local release_id = ARGV[2] or '' -- 本次解锁的请求 ID，重试时不变，为空时不去重
+
+-- 解锁记录 key：锁 key 含 hash tag 时直接追加后缀，否则以锁 key 作为 hash tag，保证与锁 key 位于同一 slot
+local release_key = local_key .. ':release:' .. lock_value
+if not string.find(local_key, '^[^{]*{[^}]+}') then
+    release_key = '{' .. local_key .. '}:release:' .. lock_value
+end
+
+-- 重试的请求已执行过（回复丢失），直接返回成功，避免重复减少计数
+if release_id ~= '' and redis.call('GET', release_key) == release_id then
+    return 1
+end
+
+-- 解锁成功，记录本次请求 ID，ARGV[3] 为记录的有效期（毫秒）
+local function released()
+    if release_id ~= '' then
+        redis.call('SET', release_key, release_id, 'PX', tonumber(ARGV[3]))
+    end
+    return 1
+end
 
 -- 获取当前持有者的读锁计数
 local self_cnt = tonumber(redis.call('HGET', local_key, 'r:' .. lock_value) or '0')
 
 -- 如果自身没有持有读锁，则解锁失败
 if self_cnt <= 0 then
-    return 0
+    if redis.call('EXISTS', local_key) == 0 then
+        return -6 -- 锁已过期
+    end
+    return -5 -- 不是读锁持有者
 end
 
 -- 减少自身读锁计数
@@ -32,4 +55,4 @@ if total <= 0 then
     end
 end
 
-return 1
\ No newline at end of file
+return released()
\ No newline at end of file
@@ -34,11 +34,11 @@
         - 如果成功，设置可重入计数器为 1，并设置过期时间；
         - 返回 1，表示加锁成功。
     3. 如果 SET NX 加锁失败，表示已有其他客户端持有锁：
-        - 返回 0，表示加锁失败。
+        - 返回 -2，表示加锁失败（锁被占用）。
 
     返回值：
     - 1：加锁成功（首次或可重入）
-    - 0 ：加锁失败（被其他客户端持有）
+    - {-2, pttl, holder}：加锁失败（被其他客户端持有），附带锁剩余有效期（毫秒）与当前持有者
 
     注意事项：
     - 锁名（KEYS[1]）应使用 Redis 的 hash tag `{}` 包裹，确保主锁和重入计数器落在同一 slot（用于 Redis Cluster）。
@@ -67,4 +67,5 @@ if redis.call('SET', lock_key, lock_value, 'NX', 'PX', lock_ttl) then
     return 1
 end
 
-return 0
+-- 锁被其他客户端持有，返回剩余有效期与持有者
+return {-2, redis.call('PTTL', lock_key), redis.call('GET', lock_key)}
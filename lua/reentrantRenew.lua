@@ -30,11 +30,12 @@
     3. 若满足续期条件：
         - 刷新主锁和重入计数器的过期时间（PEXPIRE）；
         - 返回 1 表示续期成功；
-    4. 否则（锁不存在或不是当前客户端持有），返回 nil。
+    4. 否则锁不存在（过期）返回 -6，不是当前客户端持有返回 -5。
 
     返回值：
     - 1：续期成功（当前客户端仍持有锁）
-    - 0 ：续期失败（锁不存在或非本客户端持有）
+    - -5：续期失败（非本客户端持有）
+    - -6：续期失败（锁不存在或已过期）
 
     注意事项：
     - 客户端应定时调用该脚本以实现“自动续租”功能；
@@ -53,10 +54,15 @@ local reentrant_count = tonumber(redis.call('GET', reentrant_key) or '0')
 -- 锁续期
 -- 重入锁的场景（reentrant_count > 0）
 -- 普通锁的场景（redis.call('GET', lock_key) == lock_value）
-if reentrant_count > 0 or redis.call('GET', lock_key) == lock_value then
+local holder = redis.call('GET', lock_key)
+if reentrant_count > 0 or holder == lock_value then
     redis.call('PEXPIRE', lock_key, lock_ttl)
     redis.call('PEXPIRE', reentrant_key, lock_ttl)
     return 1
 end
 
-return 0
+if not holder then
+    return -6 -- 锁已过期
+end
+
+return -5 -- 不是锁的持有者
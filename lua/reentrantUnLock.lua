@@ -12,6 +12,8 @@
     输入参数：
     KEYS[1]     - 锁的业务 key（如 "my-lock"）
     ARGV[1]     - 当前客户端标识（如 UUID，作为 lock_value）
+    ARGV[2]     - 本次解锁的请求 ID（可选，每次 UnLock 调用唯一，重试时不变；为空时不去重）
+    ARGV[3]     - 解锁记录的有效期（毫秒，release_ttl）
 
     Redis 数据结构：
     1. 主锁 key:
@@ -20,9 +22,13 @@
     2. 可重入计数器 key:
         格式：{KEYS[1]}:count:{ARGV[1]}
         值：整数，表示该客户端的持锁次数
+    3. 解锁记录 key:
+        格式：{KEYS[1]}:release:{ARGV[1]}
+        值：ARGV[2]（最近一次成功解锁的请求 ID），release_ttl 后过期
 
     执行逻辑：
     1. 构造锁名 lock_key 和可重入计数器名 reentrant_key；
+       若解锁记录等于本次请求 ID，说明该请求已执行（重试前回复丢失），直接返回 1，避免重复减少计数；
     2. 如果 reentrant_count > 1：
         - 表示客户端还持有多次锁，仅减 1 并返回 1；
     3. 如果 reentrant_count == 1：
@@ -32,11 +38,13 @@
     4. 如果计数器不存在或为 0：
         - 尝试作为普通非重入锁解锁；
         - 如果主锁的值等于客户端标识，则删除主锁，返回 1；
-    5. 以上均不满足（如不是持有者），返回 0 表示解锁失败。
+    5. 以上均不满足：锁已不存在（过期）返回 -6，被其他客户端持有返回 -5；
+    6. 解锁成功时写入解锁记录。
 
     返回值：
     - 1：解锁成功（无论是否重入）
-    - 0 ：解锁失败（锁不存在或当前客户端不是持有者）
+    - -5：解锁失败（当前客户端不是持有者）
+    - -6：解锁失败（锁不存在或已过期）
 
     注意事项：
     - 加锁和解锁脚本必须搭配使用，并保持客户端 lock_value 一致；
@@ -49,26 +57,47 @@
 local lock_key = '{' .. KEYS[1] .. '}'
 local lock_value = ARGV[1]
 local reentrant_key = lock_key .. ':count:' .. lock_value
+local release_id = ARGV[2] or ''
+local release_key = lock_key .. ':release:' .. lock_value
+
+-- 重试的请求已执行过，直接返回成功
+if release_id ~= '' and redis.call('GET', release_key) == release_id then
+    return 1
+end
+
+-- 解锁成功，记录本次请求 ID
+local function released()
+    if release_id ~= '' then
+        redis.call('SET', release_key, release_id, 'PX', tonumber(ARGV[3]))
+    end
+    return 1
+end
+
 local reentrant_count = tonumber(redis.call('GET', reentrant_key) or '0')
 
 --可重入锁解锁
 if reentrant_count > 1 then
     redis.call('DECR', reentrant_key)
-    return 1
+    return released()
 elseif reentrant_count == 1 then
     redis.call('DEL', reentrant_key)
 
     -- 如果锁的值相等，删除锁
     if redis.call('GET', lock_key) == lock_value then
         redis.call('DEL', lock_key)
-        return 1
+        return released()
     end
 end
 
 --非可重入锁解锁
-if redis.call('GET', lock_key) == lock_value then
+local holder = redis.call('GET', lock_key)
+if holder == lock_value then
     redis.call('DEL', lock_key)
-    return 1
+    return released()
+end
+
+if not holder then
+    return -6 -- 锁已过期
 end
 
-return 0
+return -5 -- 不是锁的持有者
@@ -0,0 +1,31 @@
+--[[
+    Singleflight Result Read Script (Singleflight 共享结果读取脚本)
+
+    功能描述：
+    读取 singleflight 获胜者写入的共享结果。
+
+    输入参数：
+    KEYS[1]     - 业务 key（与锁的 key 相同）
+
+    Redis 数据结构：
+    1. 共享结果 key:
+        格式：{KEYS[1]}:result
+        值：获胜者序列化后的结果（"v:" 前缀为返回值，"e:" 前缀为错误信息）
+        设置：SET PX result_ttl，独立于锁的有效期
+
+    返回值：
+    - {0}：尚无结果
+    - {1, result}：已有结果
+
+    注意事项：
+    - 不存在时返回数组而不是空回复，避免不同客户端对空回复的处理差异。
+--]]
+
+local result_key = '{' .. KEYS[1] .. '}:result'
+local result = redis.call('GET', result_key)
+
+if result then
+    return {1, result}
+end
+
+return {0}
@@ -0,0 +1,18 @@
+--[[
+    Singleflight Release Notification Script (Singleflight 释放通知脚本)
+
+    功能描述：
+    获胜者未写入结果即释放锁（如调用方取消、锁丢失）时通知等待者，
+    等待者收到通知后立即重新尝试加锁，而不是等到锁的剩余有效期结束。
+
+    输入参数：
+    KEYS[1]     - 业务 key（与锁的 key 相同）
+
+    通知频道：
+    格式：{KEYS[1]}:result
+
+    返回值：
+    - 收到通知的订阅数
+--]]
+
+return redis.call('PUBLISH', '{' .. KEYS[1] .. '}:result', 0)
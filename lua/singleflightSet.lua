@@ -0,0 +1,51 @@
+--[[
+    Singleflight Result Write Script (Singleflight 共享结果写入脚本)
+
+    功能描述：
+    获胜者（锁的持有者）执行完成后写入共享结果，并通知等待中的其他客户端。
+
+    输入参数：
+    KEYS[1]     - 业务 key（与锁的 key 相同）
+    ARGV[1]     - 当前客户端标识（锁的 Token）
+    ARGV[2]     - 序列化后的结果（"v:" 前缀为返回值，"e:" 前缀为错误信息）
+    ARGV[3]     - 结果的有效期（单位：毫秒）
+
+    Redis 数据结构：
+    1. 主锁 key:
+        格式：{KEYS[1]}
+        值：持有者标识
+    2. 共享结果 key:
+        格式：{KEYS[1]}:result
+        设置：SET PX result_ttl
+    3. 通知频道：
+        格式：{KEYS[1]}:result
+        写入结果后 PUBLISH，等待者收到后读取结果
+
+    执行逻辑：
+    1. 校验当前客户端仍持有锁，锁已过期或被他人持有时不写入，避免覆盖新获胜者的结果；
+    2. 写入结果并设置有效期；
+    3. 向通知频道发布消息，返回 1。
+
+    返回值：
+    - 1：写入成功
+    - -5：写入失败（非本客户端持有锁）
+    - -6：写入失败（锁不存在或已过期）
+--]]
+
+local lock_key = '{' .. KEYS[1] .. '}'
+local result_key = lock_key .. ':result'
+local lock_value = ARGV[1]
+local result = ARGV[2]
+local result_ttl = tonumber(ARGV[3])
+
+local holder = redis.call('GET', lock_key)
+if not holder then
+    return -6
+end
+if holder ~= lock_value then
+    return -5
+end
+
+redis.call('SET', result_key, result, 'PX', result_ttl)
+redis.call('PUBLISH', result_key, 1)
+return 1
@@ -32,8 +32,8 @@ if mode == 'write' then
         redis.call('PEXPIRE', local_key, lock_ttl)
         return 1
     else
-        -- 他人持有写锁，获取失败
-        return 0
+        -- 他人持有写锁，获取失败，返回剩余有效期与写锁持有者
+        return {-2, redis.call('PTTL', local_key), writer}
     end
 end
 
@@ -55,5 +55,13 @@ if mode == 'read' then
 end
 
 
--- 其他情况无法获取写锁（存在其他读者或写锁被他人占用）
-return 0
\ No newline at end of file
+-- 其他情况无法获取写锁（存在其他读者，锁模式不匹配）
+-- 返回剩余有效期与所有读锁持有者
+local result = {-4, redis.call('PTTL', local_key)}
+local fields = redis.call('HKEYS', local_key)
+for _, field in ipairs(fields) do
+    if string.sub(field, 1, 2) == 'r:' then
+        table.insert(result, string.sub(field, 3))
+    end
+end
+return result
\ No newline at end of file
@@ -2,11 +2,21 @@ local local_key = KEYS[1]
 local lock_value = ARGV[1]
 local lock_ttl = tonumber(ARGV[2]) or 0
 
--- 验证写锁持有者
+-- 验证锁模式与写锁持有者
+local mode = redis.call('HGET', local_key, 'mode')
+if not mode then
+    -- 锁不存在，已过期
+    return -6
+end
+if mode ~= 'write' then
+    -- 当前为读锁模式，锁模式不匹配
+    return -4
+end
+
 local writer = redis.call('HGET', local_key, 'writer')
 if writer ~= lock_value then
     -- 非写锁持有者，续期失败
-    return 0
+    return -5
 end
 
 -- 刷新 TTL
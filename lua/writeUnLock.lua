@@ -1,18 +1,48 @@
 local local_key = KEYS[1]
 local lock_value = ARGV[1] -- 当前请求解锁的持有者标识（owner）
+local release_id = ARGV[2] or '' -- 本次解锁的请求 ID，重试时不变，为空时不去重
+
+-- 解锁记录 key：锁 key 含 hash tag 时直接追加后缀，否则以锁 key 作为 hash tag，保证与锁 key 位于同一 slot
+local release_key = local_key .. ':release:' .. lock_value
+if not string.find(local_key, '^[^{]*{[^}]+}') then
+    release_key = '{' .. local_key .. '}:release:' .. lock_value
+end
+
+-- 重试的请求已执行过（回复丢失），直接返回成功，避免重复减少计数
+if release_id ~= '' and redis.call('GET', release_key) == release_id then
+    return 1
+end
+
+-- 解锁成功，记录本次请求 ID，ARGV[3] 为记录的有效期（毫秒）
+local function released()
+    if release_id ~= '' then
+        redis.call('SET', release_key, release_id, 'PX', tonumber(ARGV[3]))
+    end
+    return 1
+end
+
+-- 获取当前锁模式与写锁持有者
+local mode = redis.call('HGET', local_key, 'mode')
+if not mode then
+    -- 锁不存在，已过期
+    return -6
+end
+if mode ~= 'write' then
+    -- 当前为读锁模式，锁模式不匹配
+    return -4
+end
 
--- 获取当前写锁持有者
 local writer = redis.call('HGET', local_key, 'writer')
 if writer ~= lock_value then
     -- 如果当前线程不是写锁持有者，则解锁失败
-    return 0
+    return -5
 end
 
 -- 减少写锁计数（支持可重入锁）
 local wcount = tonumber(redis.call('HINCRBY', local_key, 'wcount', -1))
 if wcount > 0 then
     -- 写锁仍然持有（可重入计数 > 0），无需释放锁，直接返回
-    return 1
+    return released()
 end
 
 -- 写锁计数归零，释放写锁
@@ -29,4 +59,4 @@ else
     redis.call('DEL', local_key)
 end
 
-return 1
\ No newline at end of file
+return released()
\ No newline at end of file
@@ -131,6 +131,20 @@ func (m *MockRedisLockInter) EXPECT() *MockRedisLockInterMockRecorder {
 	return m.recorder
 }
 
+// FairCancel mocks base method.
+func (m *MockRedisLockInter) FairCancel(ctx context.Context, requestId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FairCancel", ctx, requestId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FairCancel indicates an expected call of FairCancel.
+func (mr *MockRedisLockInterMockRecorder) FairCancel(ctx, requestId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FairCancel", reflect.TypeOf((*MockRedisLockInter)(nil).FairCancel), ctx, requestId)
+}
+
 // FairLock mocks base method.
 func (m *MockRedisLockInter) FairLock(ctx context.Context, requestId string) error {
 	m.ctrl.T.Helper()
@@ -187,6 +201,62 @@ func (mr *MockRedisLockInterMockRecorder) Lock(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockRedisLockInter)(nil).Lock), ctx)
 }
 
+// PriorityFairLock mocks base method.
+func (m *MockRedisLockInter) PriorityFairLock(ctx context.Context, requestId string, priority int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PriorityFairLock", ctx, requestId, priority)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PriorityFairLock indicates an expected call of PriorityFairLock.
+func (mr *MockRedisLockInterMockRecorder) PriorityFairLock(ctx, requestId, priority interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PriorityFairLock", reflect.TypeOf((*MockRedisLockInter)(nil).PriorityFairLock), ctx, requestId, priority)
+}
+
+// RLock mocks base method.
+func (m *MockRedisLockInter) RLock(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RLock", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RLock indicates an expected call of RLock.
+func (mr *MockRedisLockInterMockRecorder) RLock(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RLock", reflect.TypeOf((*MockRedisLockInter)(nil).RLock), ctx)
+}
+
+// RRenew mocks base method.
+func (m *MockRedisLockInter) RRenew(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RRenew", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RRenew indicates an expected call of RRenew.
+func (mr *MockRedisLockInterMockRecorder) RRenew(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RRenew", reflect.TypeOf((*MockRedisLockInter)(nil).RRenew), ctx)
+}
+
+// RUnLock mocks base method.
+func (m *MockRedisLockInter) RUnLock(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RUnLock", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RUnLock indicates an expected call of RUnLock.
+func (mr *MockRedisLockInterMockRecorder) RUnLock(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RUnLock", reflect.TypeOf((*MockRedisLockInter)(nil).RUnLock), ctx)
+}
+
 // Renew mocks base method.
 func (m *MockRedisLockInter) Renew(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -229,6 +299,48 @@ func (mr *MockRedisLockInterMockRecorder) SpinLock(ctx, timeout interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpinLock", reflect.TypeOf((*MockRedisLockInter)(nil).SpinLock), ctx, timeout)
 }
 
+// SpinPriorityFairLock mocks base method.
+func (m *MockRedisLockInter) SpinPriorityFairLock(ctx context.Context, requestId string, priority int, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpinPriorityFairLock", ctx, requestId, priority, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SpinPriorityFairLock indicates an expected call of SpinPriorityFairLock.
+func (mr *MockRedisLockInterMockRecorder) SpinPriorityFairLock(ctx, requestId, priority, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpinPriorityFairLock", reflect.TypeOf((*MockRedisLockInter)(nil).SpinPriorityFairLock), ctx, requestId, priority, timeout)
+}
+
+// SpinRLock mocks base method.
+func (m *MockRedisLockInter) SpinRLock(ctx context.Context, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpinRLock", ctx, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SpinRLock indicates an expected call of SpinRLock.
+func (mr *MockRedisLockInterMockRecorder) SpinRLock(ctx, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpinRLock", reflect.TypeOf((*MockRedisLockInter)(nil).SpinRLock), ctx, timeout)
+}
+
+// SpinWLock mocks base method.
+func (m *MockRedisLockInter) SpinWLock(ctx context.Context, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpinWLock", ctx, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SpinWLock indicates an expected call of SpinWLock.
+func (mr *MockRedisLockInterMockRecorder) SpinWLock(ctx, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpinWLock", reflect.TypeOf((*MockRedisLockInter)(nil).SpinWLock), ctx, timeout)
+}
+
 // UnLock mocks base method.
 func (m *MockRedisLockInter) UnLock(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -242,3 +354,152 @@ func (mr *MockRedisLockInterMockRecorder) UnLock(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnLock", reflect.TypeOf((*MockRedisLockInter)(nil).UnLock), ctx)
 }
+
+// WLock mocks base method.
+func (m *MockRedisLockInter) WLock(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WLock", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WLock indicates an expected call of WLock.
+func (mr *MockRedisLockInterMockRecorder) WLock(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WLock", reflect.TypeOf((*MockRedisLockInter)(nil).WLock), ctx)
+}
+
+// WRenew mocks base method.
+func (m *MockRedisLockInter) WRenew(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WRenew", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WRenew indicates an expected call of WRenew.
+func (mr *MockRedisLockInterMockRecorder) WRenew(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WRenew", reflect.TypeOf((*MockRedisLockInter)(nil).WRenew), ctx)
+}
+
+// WUnLock mocks base method.
+func (m *MockRedisLockInter) WUnLock(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WUnLock", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WUnLock indicates an expected call of WUnLock.
+func (mr *MockRedisLockInterMockRecorder) WUnLock(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WUnLock", reflect.TypeOf((*MockRedisLockInter)(nil).WUnLock), ctx)
+}
+
+// MockRedisFairLockInter is a mock of RedisFairLockInter interface.
+type MockRedisFairLockInter struct {
+	ctrl     *gomock.Controller
+	recorder *MockRedisFairLockInterMockRecorder
+}
+
+// MockRedisFairLockInterMockRecorder is the mock recorder for MockRedisFairLockInter.
+type MockRedisFairLockInterMockRecorder struct {
+	mock *MockRedisFairLockInter
+}
+
+// NewMockRedisFairLockInter creates a new mock instance.
+func NewMockRedisFairLockInter(ctrl *gomock.Controller) *MockRedisFairLockInter {
+	mock := &MockRedisFairLockInter{ctrl: ctrl}
+	mock.recorder = &MockRedisFairLockInterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRedisFairLockInter) EXPECT() *MockRedisFairLockInterMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockRedisFairLockInter) Cancel(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockRedisFairLockInterMockRecorder) Cancel(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockRedisFairLockInter)(nil).Cancel), ctx)
+}
+
+// Lock mocks base method.
+func (m *MockRedisFairLockInter) Lock(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockRedisFairLockInterMockRecorder) Lock(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockRedisFairLockInter)(nil).Lock), ctx)
+}
+
+// Renew mocks base method.
+func (m *MockRedisFairLockInter) Renew(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Renew", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Renew indicates an expected call of Renew.
+func (mr *MockRedisFairLockInterMockRecorder) Renew(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Renew", reflect.TypeOf((*MockRedisFairLockInter)(nil).Renew), ctx)
+}
+
+// RequestId mocks base method.
+func (m *MockRedisFairLockInter) RequestId() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestId")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// RequestId indicates an expected call of RequestId.
+func (mr *MockRedisFairLockInterMockRecorder) RequestId() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestId", reflect.TypeOf((*MockRedisFairLockInter)(nil).RequestId))
+}
+
+// SpinLock mocks base method.
+func (m *MockRedisFairLockInter) SpinLock(ctx context.Context, timeout time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpinLock", ctx, timeout)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SpinLock indicates an expected call of SpinLock.
+func (mr *MockRedisFairLockInterMockRecorder) SpinLock(ctx, timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpinLock", reflect.TypeOf((*MockRedisFairLockInter)(nil).SpinLock), ctx, timeout)
+}
+
+// UnLock mocks base method.
+func (m *MockRedisFairLockInter) UnLock(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnLock", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnLock indicates an expected call of UnLock.
+func (mr *MockRedisFairLockInterMockRecorder) UnLock(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnLock", reflect.TypeOf((*MockRedisFairLockInter)(nil).UnLock), ctx)
+}
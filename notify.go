@@ -0,0 +1,61 @@
+package go_redislock
+
+import (
+	"context"
+	"time"
+)
+
+// RedisPubSubInter 支持订阅频道的客户端接口，适配器可选实现。
+// 实现后 singleflight 等需要等待他人释放的场景通过订阅通知被及时唤醒，否则以 notifyPollInterval 轮询
+type RedisPubSubInter interface {
+	// Subscribe 订阅 channel，订阅生效后返回。收到的消息写入 messages（可丢弃积压的消息），
+	// 调用 unsubscribe 取消订阅并释放连接，之后 messages 不再写入
+	Subscribe(ctx context.Context, channel string) (messages <-chan string, unsubscribe func(), err error)
+}
+
+// subscription 等待通知的订阅，客户端不支持订阅或订阅失败时退化为轮询
+type subscription struct {
+	messages    <-chan string
+	unsubscribe func()
+}
+
+// subscribe 订阅 channel。需在检查状态之前订阅，避免检查与订阅之间的通知丢失
+func subscribe(ctx context.Context, rdb RedisInter, channel string) *subscription {
+	ps, ok := rdb.(RedisPubSubInter)
+	if !ok {
+		return &subscription{}
+	}
+	messages, unsubscribe, err := ps.Subscribe(ctx, channel)
+	if err != nil {
+		return &subscription{}
+	}
+	return &subscription{messages: messages, unsubscribe: unsubscribe}
+}
+
+// wait 等待通知，最长等待 max；未订阅时最长等待 notifyPollInterval。ctx 结束时返回 ctx 的错误
+func (s *subscription) wait(ctx context.Context, max time.Duration) error {
+	if s.messages == nil {
+		max = min(max, notifyPollInterval)
+	}
+	if max <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(max)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.messages:
+	case <-timer.C:
+	}
+	return nil
+}
+
+// close 取消订阅
+func (s *subscription) close() {
+	if s != nil && s.unsubscribe != nil {
+		s.unsubscribe()
+	}
+}
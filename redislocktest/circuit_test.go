@@ -0,0 +1,159 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+
+	newBreaker := func() (*FaultInjector, *redislock.CircuitBreaker) {
+		faulty := NewFaultInjector(New())
+		cb := redislock.NewCircuitBreaker(faulty,
+			redislock.WithFailureThreshold(3),
+			redislock.WithHalfOpenInterval(50*time.Millisecond),
+		)
+		return faulty, cb
+	}
+
+	t.Run("连续传输错误后熔断", func(t *testing.T) {
+		faulty, cb := newBreaker()
+		faulty.Fail(ErrConnReset, -1)
+		lock := redislock.New(cb, "key")
+
+		for i := 0; i < 3; i++ {
+			if err := lock.Lock(ctx); !errors.Is(err, ErrConnReset) {
+				t.Fatalf("attempt %d: expected error %v, got %v", i, ErrConnReset, err)
+			}
+		}
+		if cb.State() != redislock.CircuitOpen {
+			t.Fatalf("expected state open, got %s", cb.State())
+		}
+
+		// 熔断期间不再访问 Redis
+		calls := faulty.Calls()
+		err := lock.Lock(ctx)
+		if !errors.Is(err, redislock.ErrRedisUnavailable) || !errors.Is(err, redislock.ErrException) {
+			t.Errorf("expected error %v, got %v", redislock.ErrRedisUnavailable, err)
+		}
+		if faulty.Calls() != calls {
+			t.Error("open circuit still forwarded the request")
+		}
+	})
+
+	t.Run("服务端错误不计入传输错误", func(t *testing.T) {
+		faulty, cb := newBreaker()
+		faulty.Fail(ErrLoading, 5)
+		for i := 0; i < 5; i++ {
+			_ = redislock.New(cb, "key").Lock(ctx)
+		}
+		if cb.State() != redislock.CircuitClosed {
+			t.Errorf("expected state closed, got %s", cb.State())
+		}
+	})
+
+	t.Run("半开探测成功后恢复", func(t *testing.T) {
+		faulty, cb := newBreaker()
+		faulty.Fail(ErrConnReset, 3)
+		for i := 0; i < 3; i++ {
+			_ = redislock.New(cb, "key").Lock(ctx)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		if cb.State() != redislock.CircuitHalfOpen {
+			t.Fatalf("expected state half-open, got %s", cb.State())
+		}
+		if err := redislock.New(cb, "key").Lock(ctx); err != nil {
+			t.Fatalf("probe: %v", err)
+		}
+		if cb.State() != redislock.CircuitClosed {
+			t.Errorf("expected state closed, got %s", cb.State())
+		}
+	})
+
+	t.Run("半开探测失败后继续熔断", func(t *testing.T) {
+		faulty, cb := newBreaker()
+		var transitions []redislock.CircuitState
+		cb = redislock.NewCircuitBreaker(faulty,
+			redislock.WithFailureThreshold(1),
+			redislock.WithHalfOpenInterval(50*time.Millisecond),
+			redislock.WithStateChange(func(from, to redislock.CircuitState) {
+				transitions = append(transitions, to)
+			}),
+		)
+		faulty.Fail(ErrConnReset, -1)
+
+		_ = redislock.New(cb, "key").Lock(ctx)
+		time.Sleep(60 * time.Millisecond)
+		_ = redislock.New(cb, "key").Lock(ctx)
+
+		want := []redislock.CircuitState{redislock.CircuitOpen, redislock.CircuitHalfOpen, redislock.CircuitOpen}
+		if len(transitions) != len(want) {
+			t.Fatalf("expected transitions %v, got %v", want, transitions)
+		}
+		for i := range want {
+			if transitions[i] != want[i] {
+				t.Fatalf("expected transitions %v, got %v", want, transitions)
+			}
+		}
+	})
+}
+
+func TestUnavailablePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	openBreaker := func() *redislock.CircuitBreaker {
+		faulty := NewFaultInjector(New())
+		faulty.Fail(ErrConnReset, -1)
+		cb := redislock.NewCircuitBreaker(faulty, redislock.WithFailureThreshold(1), redislock.WithHalfOpenInterval(time.Hour))
+		_ = redislock.New(cb, "warmup").Lock(ctx)
+		return cb
+	}
+
+	t.Run("默认拒绝加锁", func(t *testing.T) {
+		err := redislock.New(openBreaker(), "policy:closed").Lock(ctx)
+		if !errors.Is(err, redislock.ErrRedisUnavailable) {
+			t.Errorf("expected error %v, got %v", redislock.ErrRedisUnavailable, err)
+		}
+	})
+
+	t.Run("回退为进程内锁", func(t *testing.T) {
+		cb := openBreaker()
+		fallback := redislock.WithUnavailablePolicy(redislock.FallbackLocal)
+		a := redislock.New(cb, "policy:local", redislock.WithToken("a"), fallback)
+		b := redislock.New(cb, "policy:local", redislock.WithToken("b"), fallback)
+
+		if err := a.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		if err := a.Lock(ctx); err != nil {
+			t.Fatalf("lock reentry: %v", err)
+		}
+		if err := b.Lock(ctx); !errors.Is(err, redislock.ErrLockHeld) {
+			t.Errorf("expected error %v, got %v", redislock.ErrLockHeld, err)
+		}
+		if err := a.Renew(ctx); err != nil {
+			t.Errorf("renew: %v", err)
+		}
+
+		// Redis 恢复后，进程内锁释放前其他持有者仍无法加锁
+		if err := redislock.New(New(), "policy:local", redislock.WithToken("b"), fallback).Lock(ctx); !errors.Is(err, redislock.ErrLockHeld) {
+			t.Errorf("expected error %v, got %v", redislock.ErrLockHeld, err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := a.UnLock(ctx); err != nil {
+				t.Fatalf("unlock: %v", err)
+			}
+		}
+		if err := b.Lock(ctx); err != nil {
+			t.Errorf("lock after release: %v", err)
+		}
+		_ = b.UnLock(ctx)
+	})
+}
@@ -0,0 +1,151 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// recordLogger 记录日志内容
+type recordLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (r *recordLogger) Printf(format string, v ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, fmt.Sprintf(format, v...))
+}
+
+func (r *recordLogger) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.logs, "\n")
+}
+
+// recordMetrics 记录监控回调
+type recordMetrics struct {
+	mu     sync.Mutex
+	events []redislock.ScriptEvent
+}
+
+func (r *recordMetrics) ObserveScript(event redislock.ScriptEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// Client 的默认配置作用于所创建的锁，单个锁的配置覆盖默认配置
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("key 前缀与默认超时", func(t *testing.T) {
+		rdb := New()
+		client := redislock.NewClient(rdb, redislock.WithKeyPrefix("order:"), redislock.WithTimeout(time.Second))
+
+		lock := client.NewLock("42")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		if !rdb.Exists("{order:42}") {
+			t.Fatalf("expected prefixed key, got %v", rdb.Keys())
+		}
+
+		rdb.Clock().Advance(1100 * time.Millisecond)
+		if rdb.Exists("{order:42}") {
+			t.Error("expected lock to expire with the client default timeout")
+		}
+	})
+
+	t.Run("覆盖默认配置", func(t *testing.T) {
+		rdb := New()
+		client := redislock.NewClient(rdb, redislock.WithTimeout(time.Second))
+
+		if err := client.NewLock("key", redislock.WithTimeout(time.Minute)).Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		rdb.Clock().Advance(2 * time.Second)
+		if !rdb.Exists("{key}") {
+			t.Error("expected override timeout to apply")
+		}
+	})
+
+	t.Run("读写锁与公平锁", func(t *testing.T) {
+		client := redislock.NewClient(New(), redislock.WithKeyPrefix("p:"))
+
+		rw := client.NewRWLock("rw")
+		if err := rw.RLock(ctx); err != nil {
+			t.Fatalf("rlock: %v", err)
+		}
+		if err := client.NewRWLock("rw").WLock(ctx); !errors.Is(err, redislock.ErrWrongMode) {
+			t.Errorf("expected ErrWrongMode, got %v", err)
+		}
+		if err := rw.RUnLock(ctx); err != nil {
+			t.Errorf("runlock: %v", err)
+		}
+
+		fair := client.NewFairLock("fair")
+		if err := fair.Lock(ctx); err != nil {
+			t.Fatalf("fair lock: %v", err)
+		}
+		if err := client.NewFairLock("fair").Lock(ctx); err == nil {
+			t.Error("expected second fair lock to fail")
+		}
+		if err := fair.UnLock(ctx); err != nil {
+			t.Errorf("fair unlock: %v", err)
+		}
+	})
+
+	t.Run("监控", func(t *testing.T) {
+		metrics := &recordMetrics{}
+		client := redislock.NewClient(New(), redislock.WithMetrics(metrics))
+
+		lock := client.NewLock("a")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		if err := client.NewLock("a").Lock(ctx); err == nil {
+			t.Fatal("expected lock to be held")
+		}
+		if err := lock.UnLock(ctx); err != nil {
+			t.Fatalf("unlock: %v", err)
+		}
+
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+		var got []string
+		for _, e := range metrics.events {
+			got = append(got, e.Script+" "+e.Key)
+		}
+		want := []string{"reentrantLock a", "reentrantLock a", "reentrantUnLock a"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("expected events %v, got %v", want, got)
+		}
+	})
+
+	t.Run("日志", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		logger := &recordLogger{}
+		client := redislock.NewClient(faulty, redislock.WithLogger(logger),
+			redislock.WithTimeout(150*time.Millisecond), redislock.WithAutoRenew())
+
+		lock := client.NewLock("key")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		defer lock.UnLock(ctx)
+
+		faulty.Fail(errors.New("ERR unknown"), 1)
+		time.Sleep(100 * time.Millisecond)
+		if !strings.Contains(logger.String(), "lock lost") {
+			t.Errorf("expected renewal failure to be logged, got %q", logger.String())
+		}
+	})
+}
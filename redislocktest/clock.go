@@ -0,0 +1,38 @@
+package redislocktest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 可控的假时钟，用于确定性地测试锁过期、公平锁排队超时等与时间相关的行为
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock 创建一个从指定时间开始的假时钟
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now 返回假时钟的当前时间
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance 将假时钟向前推进 d
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set 将假时钟设置为指定时间
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
@@ -0,0 +1,538 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 与 Redis 一致的命令错误
+var (
+	errWrongType   = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errNotInteger  = errors.New("ERR value is not an integer or out of range")
+	errNotFloat    = errors.New("ERR value is not a valid float")
+	errSyntax      = errors.New("ERR syntax error")
+	errWrongNumber = errors.New("ERR wrong number of arguments")
+)
+
+// commandFunc 单条命令在内存中的实现
+type commandFunc func(k *keyspace, args []string) (interface{}, error)
+
+// commands 支持的命令，覆盖内置 Lua 脚本用到的全部命令
+var commands = map[string]commandFunc{
+	"GET":              cmdGet,
+	"SET":              cmdSet,
+	"DEL":              cmdDel,
+	"EXISTS":           cmdExists,
+	"PEXPIRE":          cmdPExpire,
+	"PTTL":             cmdPTTL,
+	"INCR":             cmdIncr(1, false),
+	"DECR":             cmdIncr(-1, false),
+	"INCRBY":           cmdIncr(1, true),
+	"DECRBY":           cmdIncr(-1, true),
+	"HGET":             cmdHGet,
+	"HSET":             cmdHSet,
+	"HINCRBY":          cmdHIncrBy,
+	"HDEL":             cmdHDel,
+	"HKEYS":            cmdHKeys,
+	"HLEN":             cmdHLen,
+	"ZADD":             cmdZAdd,
+	"ZSCORE":           cmdZScore,
+	"ZCARD":            cmdZCard,
+	"ZREM":             cmdZRem,
+	"ZRANK":            cmdZRank,
+	"ZRANGEBYSCORE":    cmdZRangeByScore,
+	"ZREMRANGEBYSCORE": cmdZRemRangeByScore,
+	"TIME":             cmdTime,
+	"PUBLISH":          cmdPublish,
+}
+
+// Do 在内存键空间上执行单条 Redis 命令，如 Do(ctx, "HGET", "key", "field")
+// 返回值类型与 Redis 回复一致：整数为 int64，字符串为 string，数组为 []interface{}，空回复为 nil
+// 状态回复（如 SET 的 OK）以 string 返回
+//
+// 多条 Do 之间不是原子的，需要原子性时请在调用方自行加锁
+func (r *Redis) Do(ctx context.Context, args ...interface{}) *Cmd {
+	if err := ctx.Err(); err != nil {
+		return &Cmd{err: err}
+	}
+	if len(args) == 0 {
+		return &Cmd{err: errWrongNumber}
+	}
+
+	strArgs := make([]string, len(args))
+	for i, arg := range args {
+		strArgs[i] = toString(arg)
+	}
+
+	name := strings.ToUpper(strArgs[0])
+	fn, ok := commands[name]
+	if !ok {
+		return &Cmd{err: fmt.Errorf("ERR unknown command '%s'", strArgs[0])}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	val, err := fn(r.ks, strArgs[1:])
+	return &Cmd{val: val, err: err}
+}
+
+// --- 类型检查 ---
+
+func (k *keyspace) checkString(key string) error {
+	if e := k.lookup(key); e != nil && (e.hash != nil || e.zset != nil) {
+		return errWrongType
+	}
+	return nil
+}
+
+func (k *keyspace) checkHash(key string) error {
+	if e := k.lookup(key); e != nil && e.hash == nil {
+		return errWrongType
+	}
+	return nil
+}
+
+func (k *keyspace) checkZSet(key string) error {
+	if e := k.lookup(key); e != nil && e.zset == nil {
+		return errWrongType
+	}
+	return nil
+}
+
+func parseInt(s string) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errNotInteger
+	}
+	return n, nil
+}
+
+// parseScore 解析 score，支持 -inf/+inf 与 ( 开区间前缀
+func parseScore(s string) (score float64, exclusive bool, err error) {
+	if strings.HasPrefix(s, "(") {
+		exclusive = true
+		s = s[1:]
+	}
+	switch strings.ToLower(s) {
+	case "-inf":
+		return math.Inf(-1), exclusive, nil
+	case "+inf", "inf":
+		return math.Inf(1), exclusive, nil
+	}
+	score, err = strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false, errNotFloat
+	}
+	return score, exclusive, nil
+}
+
+// formatScore 与 Redis 一致，以 %.17g 输出 score
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'g', 17, 64)
+}
+
+func intReply(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// --- string ---
+
+func cmdGet(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkString(args[0]); err != nil {
+		return nil, err
+	}
+	if v, ok := k.get(args[0]); ok {
+		return v, nil
+	}
+	return nil, nil
+}
+
+// cmdSet 支持 SET key value [NX|XX] [PX ms|EX s]
+func cmdSet(k *keyspace, args []string) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, errWrongNumber
+	}
+	key, value := args[0], args[1]
+
+	var nx, xx bool
+	var ttl int64
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "PX", "EX":
+			if i+1 >= len(args) {
+				return nil, errSyntax
+			}
+			n, err := parseInt(args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if n <= 0 {
+				return nil, errors.New("ERR invalid expire time in 'set' command")
+			}
+			if strings.ToUpper(args[i]) == "EX" {
+				n *= 1000
+			}
+			ttl = n
+			i++
+		default:
+			return nil, errSyntax
+		}
+	}
+	if nx && xx {
+		return nil, errSyntax
+	}
+
+	exists := k.exists(key)
+	if (nx && exists) || (xx && !exists) {
+		return nil, nil
+	}
+
+	k.set(key, value)
+	if ttl > 0 {
+		k.pexpire(key, ttl)
+	}
+	return "OK", nil
+}
+
+func cmdDel(k *keyspace, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errWrongNumber
+	}
+	var n int64
+	for _, key := range args {
+		if k.exists(key) {
+			k.del(key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdExists(k *keyspace, args []string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errWrongNumber
+	}
+	var n int64
+	for _, key := range args {
+		if k.exists(key) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdPExpire(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errWrongNumber
+	}
+	ttl, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if !k.exists(args[0]) {
+		return int64(0), nil
+	}
+	k.pexpire(args[0], ttl)
+	return int64(1), nil
+}
+
+func cmdPTTL(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errWrongNumber
+	}
+	return k.pttl(args[0]), nil
+}
+
+// cmdIncr 生成 INCR/DECR/INCRBY/DECRBY，withArg 表示增量由参数给出
+func cmdIncr(sign int64, withArg bool) commandFunc {
+	return func(k *keyspace, args []string) (interface{}, error) {
+		want := 1
+		if withArg {
+			want = 2
+		}
+		if len(args) != want {
+			return nil, errWrongNumber
+		}
+		if err := k.checkString(args[0]); err != nil {
+			return nil, err
+		}
+		if v, ok := k.get(args[0]); ok {
+			if _, err := parseInt(v); err != nil {
+				return nil, err
+			}
+		}
+		delta := int64(1)
+		if withArg {
+			n, err := parseInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+			delta = n
+		}
+		return k.incrBy(args[0], sign*delta), nil
+	}
+}
+
+// --- hash ---
+
+func cmdHGet(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkHash(args[0]); err != nil {
+		return nil, err
+	}
+	if v, ok := k.hget(args[0], args[1]); ok {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func cmdHSet(k *keyspace, args []string) (interface{}, error) {
+	if len(args) < 3 || len(args)%2 == 0 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkHash(args[0]); err != nil {
+		return nil, err
+	}
+	var added int64
+	for i := 1; i < len(args); i += 2 {
+		if _, ok := k.hget(args[0], args[i]); !ok {
+			added++
+		}
+	}
+	k.hset(args[0], args[1:]...)
+	return added, nil
+}
+
+func cmdHIncrBy(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkHash(args[0]); err != nil {
+		return nil, err
+	}
+	if v, ok := k.hget(args[0], args[1]); ok {
+		if _, err := parseInt(v); err != nil {
+			return nil, errors.New("ERR hash value is not an integer")
+		}
+	}
+	delta, err := parseInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	return k.hincrBy(args[0], args[1], delta), nil
+}
+
+func cmdHDel(k *keyspace, args []string) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkHash(args[0]); err != nil {
+		return nil, err
+	}
+	var n int64
+	for _, field := range args[1:] {
+		if _, ok := k.hget(args[0], field); ok {
+			k.hdel(args[0], field)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdHKeys(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkHash(args[0]); err != nil {
+		return nil, err
+	}
+	fields := k.hkeys(args[0])
+	res := make([]interface{}, len(fields))
+	for i, f := range fields {
+		res[i] = f
+	}
+	return res, nil
+}
+
+func cmdHLen(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkHash(args[0]); err != nil {
+		return nil, err
+	}
+	return int64(len(k.hkeys(args[0]))), nil
+}
+
+// --- zset ---
+
+// cmdZAdd 支持 ZADD key [NX] score member [score member ...]
+func cmdZAdd(k *keyspace, args []string) (interface{}, error) {
+	if len(args) < 3 {
+		return nil, errWrongNumber
+	}
+	key := args[0]
+	args = args[1:]
+
+	nx := false
+	if strings.ToUpper(args[0]) == "NX" {
+		nx = true
+		args = args[1:]
+	}
+	if len(args) == 0 || len(args)%2 != 0 {
+		return nil, errSyntax
+	}
+	if err := k.checkZSet(key); err != nil {
+		return nil, err
+	}
+
+	var added int64
+	for i := 0; i < len(args); i += 2 {
+		score, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return nil, errNotFloat
+		}
+		member := args[i+1]
+		if _, ok := k.zscore(key, member); ok {
+			if !nx {
+				k.zsetEntry(key, false).zset[member] = score
+			}
+			continue
+		}
+		k.zaddNX(key, score, member)
+		added++
+	}
+	return added, nil
+}
+
+func cmdZScore(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkZSet(args[0]); err != nil {
+		return nil, err
+	}
+	if score, ok := k.zscore(args[0], args[1]); ok {
+		return formatScore(score), nil
+	}
+	return nil, nil
+}
+
+func cmdZCard(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkZSet(args[0]); err != nil {
+		return nil, err
+	}
+	return k.zcard(args[0]), nil
+}
+
+func cmdZRem(k *keyspace, args []string) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkZSet(args[0]); err != nil {
+		return nil, err
+	}
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := k.zscore(args[0], member); ok {
+			k.zrem(args[0], member)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdZRank(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkZSet(args[0]); err != nil {
+		return nil, err
+	}
+	if rank := k.zrank(args[0], args[1]); rank >= 0 {
+		return rank, nil
+	}
+	return nil, nil
+}
+
+// zrangeArgs 解析 ZRANGEBYSCORE/ZREMRANGEBYSCORE 的 key min max
+func zrangeArgs(k *keyspace, args []string) ([]string, error) {
+	if len(args) != 3 {
+		return nil, errWrongNumber
+	}
+	if err := k.checkZSet(args[0]); err != nil {
+		return nil, err
+	}
+	min, minEx, err := parseScore(args[1])
+	if err != nil {
+		return nil, errors.New("ERR min or max is not a float")
+	}
+	max, maxEx, err := parseScore(args[2])
+	if err != nil {
+		return nil, errors.New("ERR min or max is not a float")
+	}
+
+	var members []string
+	for _, m := range k.zrangeByScore(args[0], min, max) {
+		score, _ := k.zscore(args[0], m)
+		if (minEx && score == min) || (maxEx && score == max) {
+			continue
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+func cmdZRangeByScore(k *keyspace, args []string) (interface{}, error) {
+	members, err := zrangeArgs(k, args)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]interface{}, len(members))
+	for i, m := range members {
+		res[i] = m
+	}
+	return res, nil
+}
+
+func cmdZRemRangeByScore(k *keyspace, args []string) (interface{}, error) {
+	members, err := zrangeArgs(k, args)
+	if err != nil {
+		return nil, err
+	}
+	k.zrem(args[0], members...)
+	return int64(len(members)), nil
+}
+
+// --- server ---
+
+func cmdTime(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, errWrongNumber
+	}
+	now := k.clock.Now()
+	return []interface{}{
+		strconv.FormatInt(now.Unix(), 10),
+		strconv.FormatInt(int64(now.Nanosecond()/1000), 10),
+	}, nil
+}
@@ -0,0 +1,178 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// Do 系列辅助函数：加锁、执行 fn、始终解锁
+func TestDoWithLock(t *testing.T) {
+	ctx := context.Background()
+	errBiz := errors.New("biz failed")
+
+	modes := []struct {
+		name string
+		do   func(ctx context.Context, rdb redislock.RedisInter, fn func(ctx context.Context) error, options ...redislock.DoOption) error
+		// key 锁在键空间中的 key
+		key string
+	}{
+		{name: "普通锁", key: "{key}", do: func(ctx context.Context, rdb redislock.RedisInter, fn func(ctx context.Context) error, options ...redislock.DoOption) error {
+			return redislock.Do(ctx, redislock.New(rdb, "key"), fn, options...)
+		}},
+		{name: "公平锁", key: "{key}", do: func(ctx context.Context, rdb redislock.RedisInter, fn func(ctx context.Context) error, options ...redislock.DoOption) error {
+			return redislock.DoWithFairLock(ctx, redislock.NewFair(rdb, "key"), fn, options...)
+		}},
+		{name: "读锁", key: "key", do: func(ctx context.Context, rdb redislock.RedisInter, fn func(ctx context.Context) error, options ...redislock.DoOption) error {
+			return redislock.DoRead(ctx, redislock.New(rdb, "key"), fn, options...)
+		}},
+		{name: "写锁", key: "key", do: func(ctx context.Context, rdb redislock.RedisInter, fn func(ctx context.Context) error, options ...redislock.DoOption) error {
+			return redislock.DoWrite(ctx, redislock.New(rdb, "key"), fn, options...)
+		}},
+	}
+
+	for _, m := range modes {
+		t.Run(m.name, func(t *testing.T) {
+			t.Run("执行后解锁", func(t *testing.T) {
+				rdb := New()
+				err := m.do(ctx, rdb, func(ctx context.Context) error {
+					if !rdb.Exists(m.key) {
+						t.Error("expected lock to be held while fn runs")
+					}
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("do: %v", err)
+				}
+				if rdb.Exists(m.key) {
+					t.Error("expected lock to be released")
+				}
+			})
+
+			t.Run("返回 fn 的错误", func(t *testing.T) {
+				rdb := New()
+				err := m.do(ctx, rdb, func(ctx context.Context) error { return errBiz })
+				if !errors.Is(err, errBiz) {
+					t.Fatalf("expected fn error, got %v", err)
+				}
+				if rdb.Exists(m.key) {
+					t.Error("expected lock to be released")
+				}
+			})
+
+			t.Run("panic 后解锁并重新 panic", func(t *testing.T) {
+				rdb := New()
+				defer func() {
+					if r := recover(); r != "boom" {
+						t.Errorf("expected panic to be re-raised, got %v", r)
+					}
+					if rdb.Exists(m.key) {
+						t.Error("expected lock to be released after panic")
+					}
+				}()
+				_ = m.do(ctx, rdb, func(ctx context.Context) error { panic("boom") })
+			})
+		})
+	}
+
+	t.Run("加锁失败不执行 fn", func(t *testing.T) {
+		rdb := New()
+		if err := redislock.New(rdb, "key").Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+
+		err := redislock.Do(ctx, redislock.New(rdb, "key"), func(ctx context.Context) error {
+			t.Error("fn must not run without the lock")
+			return nil
+		})
+		if !errors.Is(err, redislock.ErrLockHeld) {
+			t.Errorf("expected ErrLockHeld, got %v", err)
+		}
+	})
+
+	t.Run("自旋加锁", func(t *testing.T) {
+		rdb := New()
+		holder := redislock.New(rdb, "key")
+		if err := holder.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		time.AfterFunc(150*time.Millisecond, func() { _ = holder.UnLock(ctx) })
+
+		ran := false
+		err := redislock.Do(ctx, redislock.New(rdb, "key"), func(ctx context.Context) error {
+			ran = true
+			return nil
+		}, redislock.WithSpin(time.Second))
+		if err != nil || !ran {
+			t.Errorf("expected fn to run after spinning, err %v", err)
+		}
+	})
+
+	t.Run("解锁错误合并返回", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		err := redislock.Do(ctx, redislock.New(faulty, "key"), func(ctx context.Context) error {
+			faulty.Fail(errors.New("ERR unknown"), 1)
+			return errBiz
+		})
+		if !errors.Is(err, errBiz) || !errors.Is(err, redislock.ErrException) {
+			t.Errorf("expected fn and unlock errors, got %v", err)
+		}
+	})
+
+	t.Run("调用方 ctx 取消后仍解锁", func(t *testing.T) {
+		rdb := New()
+		ctxCancel, cancel := context.WithCancel(ctx)
+		err := redislock.Do(ctxCancel, redislock.New(rdb, "key"), func(ctx context.Context) error {
+			cancel()
+			return ctx.Err()
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if rdb.Exists("{key}") {
+			t.Error("expected lock to be released")
+		}
+	})
+
+	t.Run("自动续期判定锁丢失时取消 fn", func(t *testing.T) {
+		rdb := New()
+		lock := redislock.New(rdb, "key", redislock.WithTimeout(300*time.Millisecond), redislock.WithAutoRenew(),
+			redislock.WithLogger(&recordLogger{}))
+
+		err := redislock.Do(ctx, lock, func(ctx context.Context) error {
+			for _, key := range rdb.Keys() {
+				if strings.HasPrefix(key, "{key}") {
+					rdb.Do(ctx, "DEL", key)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				if !errors.Is(context.Cause(ctx), redislock.ErrLockLost) {
+					t.Errorf("expected cause ErrLockLost, got %v", context.Cause(ctx))
+				}
+				return ctx.Err()
+			case <-time.After(time.Second):
+				t.Error("fn context not cancelled after lock loss")
+				return nil
+			}
+		})
+		if !errors.Is(err, redislock.ErrLockLost) || !errors.Is(err, redislock.ErrLockExpired) {
+			t.Errorf("expected ErrLockLost, got %v", err)
+		}
+	})
+
+	t.Run("未开启自动续期时锁到期取消 fn", func(t *testing.T) {
+		lock := redislock.New(New(), "key", redislock.WithTimeout(50*time.Millisecond))
+		err := redislock.Do(ctx, lock, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if !errors.Is(err, redislock.ErrLockLost) {
+			t.Errorf("expected ErrLockLost, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// 选主：Campaign 阻塞直到当选，Resign 后其他候选者当选，任期单调递增
+func TestElection(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("当选与退位", func(t *testing.T) {
+		rdb := &subscribeCounter{Redis: New()}
+		a := redislock.NewElection(rdb, "svc", "10.0.0.1", redislock.WithToken("a"))
+		b := redislock.NewElection(rdb, "svc", "10.0.0.2", redislock.WithToken("b"))
+
+		if _, err := a.Leader(ctx); !errors.Is(err, redislock.ErrNoLeader) {
+			t.Fatalf("expected no leader, got %v", err)
+		}
+		if err := a.Campaign(ctx); err != nil {
+			t.Fatalf("campaign: %v", err)
+		}
+		// 直接当选时无需订阅
+		if n := rdb.subscribes.Load(); n != 0 {
+			t.Errorf("expected no subscription when elected at once, got %d", n)
+		}
+		if a.Term() != 1 {
+			t.Errorf("expected term 1, got %d", a.Term())
+		}
+		// 已是 leader 时直接返回，任期不变
+		if err := a.Campaign(ctx); err != nil || a.Term() != 1 {
+			t.Errorf("expected repeated campaign to keep term 1, got %d, %v", a.Term(), err)
+		}
+
+		leader, err := b.Leader(ctx)
+		if err != nil {
+			t.Fatalf("leader: %v", err)
+		}
+		if want := (redislock.LeaderInfo{ID: "a", Metadata: "10.0.0.1", Term: 1}); leader != want {
+			t.Errorf("expected %+v, got %+v", want, leader)
+		}
+
+		elected := make(chan error, 1)
+		go func() { elected <- b.Campaign(ctx) }()
+		waitSubscribers(t, rdb.Redis, "{svc}:leader", 1)
+		select {
+		case err := <-elected:
+			t.Fatalf("expected b to block while a leads, got %v", err)
+		default:
+		}
+
+		if err := a.Resign(ctx); err != nil {
+			t.Fatalf("resign: %v", err)
+		}
+		if a.Term() != 0 {
+			t.Errorf("expected term 0 after resign, got %d", a.Term())
+		}
+
+		// 退位通知及时唤醒候选者，无需等待锁到期
+		select {
+		case err := <-elected:
+			if err != nil {
+				t.Fatalf("campaign: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected b to be elected after resign")
+		}
+		if b.Term() != 2 {
+			t.Errorf("expected term 2, got %d", b.Term())
+		}
+		leader, err = a.Leader(ctx)
+		if want := (redislock.LeaderInfo{ID: "b", Metadata: "10.0.0.2", Term: 2}); err != nil || leader != want {
+			t.Errorf("expected %+v, got %+v, %v", want, leader, err)
+		}
+		if err = b.Resign(ctx); err != nil {
+			t.Fatalf("resign: %v", err)
+		}
+		if rdb.Subscribers("{svc}:leader") != 0 {
+			t.Error("expected subscriptions to be released")
+		}
+	})
+
+	t.Run("ctx 结束时停止参选", func(t *testing.T) {
+		rdb := New()
+		a := redislock.NewElection(rdb, "svc", "")
+		if err := a.Campaign(ctx); err != nil {
+			t.Fatalf("campaign: %v", err)
+		}
+		defer a.Resign(ctx)
+
+		ctxTimeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		b := redislock.NewElection(rdb, "svc", "")
+		if err := b.Campaign(ctxTimeout); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+		if b.Term() != 0 {
+			t.Errorf("expected b not to be leader, got term %d", b.Term())
+		}
+	})
+
+	t.Run("leader 崩溃后锁到期", func(t *testing.T) {
+		rdb := New()
+		a := redislock.NewElection(rdb, "svc", "", redislock.WithTimeout(300*time.Millisecond))
+		b := redislock.NewElection(rdb, "svc", "", redislock.WithTimeout(300*time.Millisecond))
+		if err := a.Campaign(ctx); err != nil {
+			t.Fatalf("campaign: %v", err)
+		}
+
+		// 续期前锁已过期，a 的续期判定锁丢失
+		rdb.Clock().Advance(time.Second)
+		deadline := time.Now().Add(2 * time.Second)
+		for a.Term() != 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("expected a to lose leadership")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if err := b.Campaign(ctx); err != nil {
+			t.Fatalf("campaign: %v", err)
+		}
+		if b.Term() != 2 {
+			t.Errorf("expected term to keep increasing, got %d", b.Term())
+		}
+		_ = b.Resign(ctx)
+	})
+
+	t.Run("观察 leader 变化", func(t *testing.T) {
+		rdb := New()
+		a := redislock.NewElection(rdb, "svc", "meta-a", redislock.WithToken("a"))
+		b := redislock.NewElection(rdb, "svc", "meta-b", redislock.WithToken("b"))
+
+		ctxObserve, cancel := context.WithCancel(ctx)
+		changes := a.Observe(ctxObserve)
+
+		next := func() redislock.LeaderInfo {
+			t.Helper()
+			select {
+			case info := <-changes:
+				return info
+			case <-time.After(time.Second):
+				t.Fatal("expected leader change")
+				return redislock.LeaderInfo{}
+			}
+		}
+
+		if info := next(); info != (redislock.LeaderInfo{}) {
+			t.Errorf("expected no leader first, got %+v", info)
+		}
+		if err := a.Campaign(ctx); err != nil {
+			t.Fatalf("campaign: %v", err)
+		}
+		if info := next(); info.ID != "a" || info.Term != 1 || info.Metadata != "meta-a" {
+			t.Errorf("expected a with term 1, got %+v", info)
+		}
+		if err := a.Resign(ctx); err != nil {
+			t.Fatalf("resign: %v", err)
+		}
+		if info := next(); info != (redislock.LeaderInfo{}) {
+			t.Errorf("expected no leader after resign, got %+v", info)
+		}
+		if err := b.Campaign(ctx); err != nil {
+			t.Fatalf("campaign: %v", err)
+		}
+		if info := next(); info.ID != "b" || info.Term != 2 {
+			t.Errorf("expected b with term 2, got %+v", info)
+		}
+
+		cancel()
+		for range changes {
+		}
+		_ = b.Resign(ctx)
+	})
+
+	t.Run("客户端默认配置", func(t *testing.T) {
+		rdb := New()
+		client := redislock.NewClient(rdb, redislock.WithKeyPrefix("app:"))
+		election := client.NewElection("svc", "meta")
+		if err := election.Campaign(ctx); err != nil {
+			t.Fatalf("campaign: %v", err)
+		}
+		if !rdb.Exists("{app:svc}") || !rdb.Exists("{app:svc}:leader") {
+			t.Errorf("expected prefixed keys, got %v", rdb.Keys())
+		}
+		if err := election.Resign(ctx); err != nil {
+			t.Fatalf("resign: %v", err)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// 常见的故障切换错误，与 Redis 服务端、客户端返回的错误一致
+var (
+	// ErrReadOnly 主从切换后连接仍指向已降级为副本的旧主节点
+	ErrReadOnly = errors.New("READONLY You can't write against a read only replica.")
+	// ErrMoved 集群槽位已迁移
+	ErrMoved = errors.New("MOVED 3999 127.0.0.1:6381")
+	// ErrAsk 集群槽位迁移中
+	ErrAsk = errors.New("ASK 3999 127.0.0.1:6381")
+	// ErrLoading 节点正在加载数据
+	ErrLoading = errors.New("LOADING Redis is loading the dataset in memory")
+	// ErrConnReset 连接被重置
+	ErrConnReset = fmt.Errorf("read tcp 127.0.0.1:52814->127.0.0.1:6379: %w", syscall.ECONNRESET)
+)
+
+// FaultInjector 包装 RedisInter 并按需注入错误，用于测试故障切换、网络错误等场景
+//
+//	rdb := redislocktest.New()
+//	faulty := redislocktest.NewFaultInjector(rdb)
+//	lock := redislock.New(faulty, "key", redislock.WithAutoRenew())
+//	faulty.Fail(redislocktest.ErrReadOnly, 3) // 之后的 3 次调用返回 READONLY
+type FaultInjector struct {
+	rdb redislock.RedisInter
+
+	mu        sync.Mutex
+	err       error
+	remaining int  // 剩余注入次数，小于 0 表示一直注入直到 Heal
+	drop      bool // 执行脚本后丢弃回复，模拟请求已执行但回复丢失
+	calls     int
+	failures  int
+}
+
+// NewFaultInjector 创建包装 rdb 的 FaultInjector，初始时不注入错误
+func NewFaultInjector(rdb redislock.RedisInter) *FaultInjector {
+	return &FaultInjector{rdb: rdb}
+}
+
+// Fail 之后的 n 次调用返回 err 且不执行脚本，n 小于 0 表示一直返回 err 直到调用 Heal
+func (f *FaultInjector) Fail(err error, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+	f.remaining = n
+	f.drop = false
+}
+
+// Drop 之后的 n 次调用照常执行脚本，但丢弃回复并返回 err，模拟请求已执行但回复丢失（如读超时、连接被重置）。
+// n 小于 0 表示一直丢弃直到调用 Heal
+func (f *FaultInjector) Drop(err error, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+	f.remaining = n
+	f.drop = true
+}
+
+// Heal 停止注入错误
+func (f *FaultInjector) Heal() {
+	f.Fail(nil, 0)
+}
+
+// Calls 返回 Eval 的调用次数（包括被注入错误的调用）
+func (f *FaultInjector) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// Failures 返回被注入错误的调用次数
+func (f *FaultInjector) Failures() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failures
+}
+
+// Eval 注入错误时返回错误（Drop 时先执行脚本），否则转发给被包装的 RedisInter
+func (f *FaultInjector) Eval(ctx context.Context, script string, keys []string, args ...interface{}) redislock.RedisCmd {
+	drop, err := f.next()
+	if err == nil {
+		return f.rdb.Eval(ctx, script, keys, args...)
+	}
+	if drop {
+		_, _ = f.rdb.Eval(ctx, script, keys, args...).Result()
+	}
+	return &Cmd{err: err}
+}
+
+// next 记录一次调用，返回是否需要先执行脚本，以及本次需要注入的错误
+func (f *FaultInjector) next() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if f.err == nil || f.remaining == 0 {
+		return false, nil
+	}
+	if f.remaining > 0 {
+		f.remaining--
+	}
+	f.failures++
+	return f.drop, f.err
+}
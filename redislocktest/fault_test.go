@@ -0,0 +1,112 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// 故障切换引起的错误与锁本身的状态区分开
+func TestFailoverErrors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		err      error
+		failover bool
+	}{
+		{name: "READONLY", err: ErrReadOnly, failover: true},
+		{name: "MOVED", err: ErrMoved, failover: true},
+		{name: "ASK", err: ErrAsk, failover: true},
+		{name: "LOADING", err: ErrLoading, failover: true},
+		{name: "连接被重置", err: ErrConnReset, failover: true},
+		{name: "连接被关闭", err: io.EOF, failover: true},
+		{name: "其他错误", err: errors.New("ERR unknown"), failover: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faulty := NewFaultInjector(New())
+			faulty.Fail(tt.err, 1)
+
+			err := redislock.New(faulty, "key").Lock(ctx)
+			if !errors.Is(err, redislock.ErrException) || !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v wrapped in ErrException, got %v", tt.err, err)
+			}
+			if errors.Is(err, redislock.ErrFailover) != tt.failover {
+				t.Errorf("errors.Is(err, ErrFailover) = %v, want %v", !tt.failover, tt.failover)
+			}
+			if errors.Is(err, redislock.ErrNotOwner) {
+				t.Errorf("failover error reported as ErrNotOwner: %v", err)
+			}
+		})
+	}
+}
+
+// waitCalls 等待 d 后返回 FaultInjector 在这段时间内的调用次数
+func waitCalls(f *FaultInjector, d time.Duration) int {
+	before := f.Calls()
+	time.Sleep(d)
+	return f.Calls() - before
+}
+
+// 自动续期在宽限期内重试故障切换错误，超过宽限期或锁已丢失时停止续期
+func TestRenewFailover(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("故障切换恢复后继续续期", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		lock := redislock.New(faulty, "key", redislock.WithTimeout(300*time.Millisecond),
+			redislock.WithAutoRenew(), redislock.WithFailoverGrace(time.Second))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		defer lock.UnLock(ctx)
+
+		faulty.Fail(ErrReadOnly, 2)
+		time.Sleep(700 * time.Millisecond)
+		if got := faulty.Failures(); got != 2 {
+			t.Fatalf("expected 2 failed renewals, got %d", got)
+		}
+		if n := waitCalls(faulty, 300*time.Millisecond); n == 0 {
+			t.Error("renewal stopped after failover recovered")
+		}
+	})
+
+	t.Run("超过宽限期判定锁丢失", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		lock := redislock.New(faulty, "key", redislock.WithTimeout(300*time.Millisecond),
+			redislock.WithAutoRenew(), redislock.WithFailoverGrace(300*time.Millisecond))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+
+		faulty.Fail(ErrLoading, -1)
+		time.Sleep(time.Second)
+		if n := waitCalls(faulty, 400*time.Millisecond); n != 0 {
+			t.Errorf("renewal still running after grace window: %d calls", n)
+		}
+	})
+
+	t.Run("故障切换后锁已丢失", func(t *testing.T) {
+		rdb := New()
+		faulty := NewFaultInjector(rdb)
+		lock := redislock.New(faulty, "key", redislock.WithTimeout(300*time.Millisecond),
+			redislock.WithAutoRenew(), redislock.WithFailoverGrace(time.Second))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+
+		// 新主节点上没有这把锁
+		faulty.Fail(ErrReadOnly, 1)
+		rdb.FlushAll()
+		time.Sleep(500 * time.Millisecond)
+		if n := waitCalls(faulty, 400*time.Millisecond); n != 0 {
+			t.Errorf("renewal still running after lock was lost: %d calls", n)
+		}
+	})
+}
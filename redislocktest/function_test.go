@@ -0,0 +1,143 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// functionRedis 在内存 Redis 上模拟 Redis Functions：FUNCTION LOAD 之前调用函数返回 Function not found，
+// 加载后函数按名称转发给对应的内置脚本
+type functionRedis struct {
+	*Redis
+
+	loaded  atomic.Bool
+	loads   atomic.Int32
+	checks  atomic.Int32
+	release chan struct{} // 非空时 FunctionLoad 阻塞直到关闭
+	// 模拟 Redis 7.0 以下的服务端，FCALL 与 FUNCTION 命令不存在
+	unsupported bool
+}
+
+func (f *functionRedis) FunctionLoad(ctx context.Context, code string) error {
+	if f.unsupported {
+		return errors.New("ERR unknown command 'FUNCTION', with args beginning with: 'LOAD'")
+	}
+	f.loads.Add(1)
+	if f.release != nil {
+		<-f.release
+	}
+	f.loaded.Store(true)
+	return nil
+}
+
+func (f *functionRedis) FCall(ctx context.Context, function string, keys []string, args ...interface{}) redislock.RedisCmd {
+	library, _ := redislock.FunctionLibrary()
+	if f.unsupported {
+		f.checks.Add(1)
+		return NewCmd(nil, errors.New("ERR unknown command 'FCALL', with args beginning with: "))
+	}
+	if !f.loaded.Load() {
+		if function == library+"_version" {
+			f.checks.Add(1)
+		}
+		return NewCmd(nil, errors.New("ERR Function not found"))
+	}
+	if function == library+"_version" {
+		f.checks.Add(1)
+		return NewCmd(strings.TrimPrefix(library, "redislock_"), nil)
+	}
+	return f.Redis.Eval(ctx, redislock.Scripts()[strings.TrimPrefix(function, library+"_")], keys, args...)
+}
+
+// 函数库加载状态由同一 Client 创建的锁共享，单独创建的锁按 Redis 客户端共享，并发的首次调用只加载一次
+func TestFunctions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("并发调用只加载一次", func(t *testing.T) {
+		rdb := &functionRedis{Redis: New(), release: make(chan struct{})}
+		client := redislock.NewClient(rdb)
+
+		const locks = 5
+		var wg sync.WaitGroup
+		errs := make(chan error, locks)
+		for i := 0; i < locks; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				lock := client.NewLock(string(rune('a' + i)))
+				if err := lock.Lock(ctx); err != nil {
+					errs <- err
+				}
+			}(i)
+		}
+
+		for rdb.loads.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		// 加载期间等待者可因 ctx 结束而返回，不被加载阻塞
+		ctxTimeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if err := client.NewLock("z").Lock(ctxTimeout); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected waiter to return with ctx, got %v", err)
+		}
+
+		close(rdb.release)
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("lock: %v", err)
+		}
+		if rdb.loads.Load() != 1 {
+			t.Errorf("expected library to be loaded once, got %d", rdb.loads.Load())
+		}
+	})
+
+	t.Run("状态保存在客户端", func(t *testing.T) {
+		rdb := &functionRedis{Redis: New()}
+		client := redislock.NewClient(rdb)
+		for _, key := range []string{"a", "b"} {
+			if err := client.NewLock(key).Lock(ctx); err != nil {
+				t.Fatalf("lock: %v", err)
+			}
+		}
+		if rdb.checks.Load() != 1 || rdb.loads.Load() != 1 {
+			t.Errorf("expected client locks to share state, got %d checks, %d loads", rdb.checks.Load(), rdb.loads.Load())
+		}
+
+	})
+
+	t.Run("单独创建的锁按客户端共享状态", func(t *testing.T) {
+		rdb := &functionRedis{Redis: New()}
+		for _, key := range []string{"a", "b", "c", "d"} {
+			lock := redislock.New(rdb, key)
+			if err := lock.Lock(ctx); err != nil {
+				t.Fatalf("lock: %v", err)
+			}
+			if err := lock.UnLock(ctx); err != nil {
+				t.Fatalf("unlock: %v", err)
+			}
+		}
+		if rdb.checks.Load() != 1 || rdb.loads.Load() != 1 {
+			t.Errorf("expected one version check and load, got %d checks, %d loads", rdb.checks.Load(), rdb.loads.Load())
+		}
+	})
+
+	t.Run("服务端不支持时只检查一次", func(t *testing.T) {
+		rdb := &functionRedis{Redis: New(), unsupported: true}
+		for _, key := range []string{"a", "b", "c"} {
+			if err := redislock.New(rdb, key).Lock(ctx); err != nil {
+				t.Fatalf("lock: %v", err)
+			}
+		}
+		if rdb.checks.Load() != 1 || rdb.loads.Load() != 0 {
+			t.Errorf("expected a single failed FCALL, got %d checks, %d loads", rdb.checks.Load(), rdb.loads.Load())
+		}
+	})
+}
@@ -0,0 +1,279 @@
+package redislocktest
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// entry 键空间中的一个 key，value 为 string、hash 或 zset 之一
+type entry struct {
+	str      string
+	hash     map[string]string
+	zset     map[string]float64
+	expireAt time.Time // 零值表示未设置过期时间
+}
+
+// keyspace 内存键空间，所有方法均需在持有 Redis.mu 时调用
+type keyspace struct {
+	clock *Clock
+	data  map[string]*entry
+	// 频道的订阅者，PUBLISH 时向其发送消息
+	subs map[string]map[chan string]struct{}
+}
+
+func newKeyspace(clock *Clock) *keyspace {
+	return &keyspace{clock: clock, data: make(map[string]*entry)}
+}
+
+// lookup 查找 key，已过期的 key 会被惰性删除
+func (k *keyspace) lookup(key string) *entry {
+	e, ok := k.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !k.clock.Now().Before(e.expireAt) {
+		delete(k.data, key)
+		return nil
+	}
+	return e
+}
+
+func (k *keyspace) exists(key string) bool {
+	return k.lookup(key) != nil
+}
+
+func (k *keyspace) del(key string) {
+	delete(k.data, key)
+}
+
+// pexpire 设置过期时间，ttl <= 0 时与 Redis 一致直接删除 key
+func (k *keyspace) pexpire(key string, ttl int64) {
+	e := k.lookup(key)
+	if e == nil {
+		return
+	}
+	if ttl <= 0 {
+		k.del(key)
+		return
+	}
+	e.expireAt = k.clock.Now().Add(time.Duration(ttl) * time.Millisecond)
+}
+
+// pttl 与 Redis PTTL 一致：-2 表示 key 不存在，-1 表示未设置过期时间
+func (k *keyspace) pttl(key string) int64 {
+	e := k.lookup(key)
+	if e == nil {
+		return -2
+	}
+	if e.expireAt.IsZero() {
+		return -1
+	}
+	return e.expireAt.Sub(k.clock.Now()).Milliseconds()
+}
+
+// nowMillis 当前毫秒时间戳
+func (k *keyspace) nowMillis() int64 {
+	return k.clock.Now().UnixMilli()
+}
+
+// --- string ---
+
+func (k *keyspace) get(key string) (string, bool) {
+	e := k.lookup(key)
+	if e == nil || e.hash != nil || e.zset != nil {
+		return "", false
+	}
+	return e.str, true
+}
+
+// set 写入 string，会清除原有过期时间
+func (k *keyspace) set(key, value string) {
+	k.data[key] = &entry{str: value}
+}
+
+// setNXPX 对应 SET key value NX PX ttl
+func (k *keyspace) setNXPX(key, value string, ttl int64) bool {
+	if k.exists(key) {
+		return false
+	}
+	k.set(key, value)
+	k.pexpire(key, ttl)
+	return true
+}
+
+func (k *keyspace) incrBy(key string, delta int64) int64 {
+	e := k.lookup(key)
+	if e == nil {
+		e = &entry{}
+		k.data[key] = e
+	}
+	n, _ := strconv.ParseInt(e.str, 10, 64)
+	n += delta
+	e.str = strconv.FormatInt(n, 10)
+	return n
+}
+
+// --- hash ---
+
+func (k *keyspace) hashEntry(key string, create bool) *entry {
+	e := k.lookup(key)
+	if e == nil && create {
+		e = &entry{hash: make(map[string]string)}
+		k.data[key] = e
+	}
+	if e == nil || e.hash == nil {
+		return nil
+	}
+	return e
+}
+
+func (k *keyspace) hget(key, field string) (string, bool) {
+	e := k.hashEntry(key, false)
+	if e == nil {
+		return "", false
+	}
+	v, ok := e.hash[field]
+	return v, ok
+}
+
+func (k *keyspace) hset(key string, pairs ...string) {
+	e := k.hashEntry(key, true)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		e.hash[pairs[i]] = pairs[i+1]
+	}
+}
+
+func (k *keyspace) hincrBy(key, field string, delta int64) int64 {
+	e := k.hashEntry(key, true)
+	n, _ := strconv.ParseInt(e.hash[field], 10, 64)
+	n += delta
+	e.hash[field] = strconv.FormatInt(n, 10)
+	return n
+}
+
+// hdel 删除字段，hash 为空时与 Redis 一致删除整个 key
+func (k *keyspace) hdel(key string, fields ...string) {
+	e := k.hashEntry(key, false)
+	if e == nil {
+		return
+	}
+	for _, f := range fields {
+		delete(e.hash, f)
+	}
+	if len(e.hash) == 0 {
+		k.del(key)
+	}
+}
+
+func (k *keyspace) hkeys(key string) []string {
+	e := k.hashEntry(key, false)
+	if e == nil {
+		return nil
+	}
+	fields := make([]string, 0, len(e.hash))
+	for f := range e.hash {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// --- zset ---
+
+func (k *keyspace) zsetEntry(key string, create bool) *entry {
+	e := k.lookup(key)
+	if e == nil && create {
+		e = &entry{zset: make(map[string]float64)}
+		k.data[key] = e
+	}
+	if e == nil || e.zset == nil {
+		return nil
+	}
+	return e
+}
+
+// zaddNX 对应 ZADD key NX score member
+func (k *keyspace) zaddNX(key string, score float64, member string) {
+	e := k.zsetEntry(key, true)
+	if _, ok := e.zset[member]; !ok {
+		e.zset[member] = score
+	}
+}
+
+func (k *keyspace) zscore(key, member string) (float64, bool) {
+	e := k.zsetEntry(key, false)
+	if e == nil {
+		return 0, false
+	}
+	score, ok := e.zset[member]
+	return score, ok
+}
+
+func (k *keyspace) zcard(key string) int64 {
+	e := k.zsetEntry(key, false)
+	if e == nil {
+		return 0
+	}
+	return int64(len(e.zset))
+}
+
+// zrem 删除成员，zset 为空时与 Redis 一致删除整个 key
+func (k *keyspace) zrem(key string, members ...string) {
+	e := k.zsetEntry(key, false)
+	if e == nil {
+		return
+	}
+	for _, m := range members {
+		delete(e.zset, m)
+	}
+	if len(e.zset) == 0 {
+		k.del(key)
+	}
+}
+
+// zmembers 按 score 升序返回全部成员，score 相同时按成员字典序
+func (k *keyspace) zmembers(key string) []string {
+	e := k.zsetEntry(key, false)
+	if e == nil {
+		return nil
+	}
+	members := make([]string, 0, len(e.zset))
+	for m := range e.zset {
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		si, sj := e.zset[members[i]], e.zset[members[j]]
+		if si != sj {
+			return si < sj
+		}
+		return members[i] < members[j]
+	})
+	return members
+}
+
+// zrank 返回成员排名，成员不存在时返回 -1
+func (k *keyspace) zrank(key, member string) int64 {
+	for i, m := range k.zmembers(key) {
+		if m == member {
+			return int64(i)
+		}
+	}
+	return -1
+}
+
+// zrangeByScore 返回 score 在 [min, max] 之间的成员
+func (k *keyspace) zrangeByScore(key string, min, max float64) []string {
+	e := k.zsetEntry(key, false)
+	var members []string
+	for _, m := range k.zmembers(key) {
+		if s := e.zset[m]; s >= min && s <= max {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+func (k *keyspace) zremRangeByScore(key string, min, max float64) {
+	k.zrem(key, k.zrangeByScore(key, min, max)...)
+}
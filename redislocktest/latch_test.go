@@ -0,0 +1,246 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// 分布式 CountDownLatch：计数归零时唤醒等待者，latch 到期后自动删除，到期前未归零的等待者返回 ErrLatchExpired
+func TestCountDownLatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("设置与计数", func(t *testing.T) {
+		rdb := New()
+		latch := redislock.NewCountDownLatch(rdb, "job", time.Hour)
+
+		if _, err := latch.TrySetCount(ctx, 0); !errors.Is(err, redislock.ErrInvalidCount) {
+			t.Errorf("expected invalid count, got %v", err)
+		}
+		if ok, err := latch.TrySetCount(ctx, 2); err != nil || !ok {
+			t.Fatalf("try set count: %v, %v", ok, err)
+		}
+		// 已存在时不修改
+		if ok, err := latch.TrySetCount(ctx, 5); err != nil || ok {
+			t.Errorf("expected existing latch to be kept, got %v, %v", ok, err)
+		}
+
+		for want := int64(2); want >= 0; want-- {
+			if count, err := latch.GetCount(ctx); err != nil || count != want {
+				t.Errorf("expected count %d, got %d, %v", want, count, err)
+			}
+			if err := latch.CountDown(ctx); err != nil {
+				t.Fatalf("count down: %v", err)
+			}
+		}
+		// 归零后保留完成标记，继续 CountDown 不会变为负数
+		if err := latch.CountDown(ctx); err != nil {
+			t.Fatalf("count down: %v", err)
+		}
+		if count, err := latch.GetCount(ctx); err != nil || count != 0 {
+			t.Errorf("expected count to stay 0, got %d, %v", count, err)
+		}
+		if ok, err := latch.Await(ctx, time.Second); err != nil || !ok {
+			t.Errorf("expected await on finished latch to return true, got %v, %v", ok, err)
+		}
+		// 归零后可重新设置
+		if ok, err := latch.TrySetCount(ctx, 1); err != nil || !ok {
+			t.Errorf("expected latch to be reusable, got %v, %v", ok, err)
+		}
+	})
+
+	t.Run("归零时唤醒等待者", func(t *testing.T) {
+		rdb := New()
+		latch := redislock.NewCountDownLatch(rdb, "job", time.Hour)
+		if _, err := latch.TrySetCount(ctx, 2); err != nil {
+			t.Fatalf("try set count: %v", err)
+		}
+
+		const waiters = 3
+		done := make(chan bool, waiters)
+		for i := 0; i < waiters; i++ {
+			go func() {
+				ok, err := latch.Await(ctx, time.Minute)
+				if err != nil {
+					t.Errorf("await: %v", err)
+				}
+				done <- ok
+			}()
+		}
+		waitSubscribers(t, rdb, "{job}:latch", waiters)
+
+		_ = latch.CountDown(ctx)
+		select {
+		case <-done:
+			t.Fatal("expected waiters to block until zero")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		_ = latch.CountDown(ctx)
+		for i := 0; i < waiters; i++ {
+			select {
+			case ok := <-done:
+				if !ok {
+					t.Error("expected await to report zero")
+				}
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("expected waiters to wake promptly")
+			}
+		}
+		if rdb.Subscribers("{job}:latch") != 0 {
+			t.Error("expected subscriptions to be released")
+		}
+	})
+
+	t.Run("等待超时", func(t *testing.T) {
+		rdb := New()
+		latch := redislock.NewCountDownLatch(rdb, "job", time.Hour)
+		_, _ = latch.TrySetCount(ctx, 1)
+
+		start := time.Now()
+		ok, err := latch.Await(ctx, 50*time.Millisecond)
+		if err != nil || ok {
+			t.Errorf("expected timeout, got %v, %v", ok, err)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("expected await to wait for timeout, returned after %v", elapsed)
+		}
+
+		ctxCancel, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if _, err = latch.Await(ctxCancel, 0); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected ctx error, got %v", err)
+		}
+	})
+
+	t.Run("latch 到期", func(t *testing.T) {
+		rdb := New()
+		latch := redislock.NewCountDownLatch(rdb, "job", time.Minute)
+		_, _ = latch.TrySetCount(ctx, 3)
+
+		// CountDown 刷新有效期
+		rdb.Clock().Advance(50 * time.Second)
+		_ = latch.CountDown(ctx)
+		rdb.Clock().Advance(50 * time.Second)
+		if count, _ := latch.GetCount(ctx); count != 2 {
+			t.Errorf("expected count down to refresh ttl, got count %d", count)
+		}
+
+		rdb.Clock().Advance(time.Minute)
+		if rdb.Exists("{job}:latch") {
+			t.Error("expected abandoned latch to expire")
+		}
+		if ok, err := latch.Await(ctx, time.Second); !errors.Is(err, redislock.ErrLatchExpired) || ok {
+			t.Errorf("expected expired latch to report ErrLatchExpired, got %v, %v", ok, err)
+		}
+
+		// 完成标记同样在 ttl 后过期
+		_, _ = latch.TrySetCount(ctx, 1)
+		_ = latch.CountDown(ctx)
+		rdb.Clock().Advance(time.Minute)
+		if rdb.Exists("{job}:latch") {
+			t.Error("expected finished latch to expire")
+		}
+	})
+
+	t.Run("等待期间到期", func(t *testing.T) {
+		rdb := New()
+		latch := redislock.NewCountDownLatch(rdb, "job", 50*time.Millisecond)
+		_, _ = latch.TrySetCount(ctx, 2)
+		_ = latch.CountDown(ctx)
+
+		done := make(chan error, 1)
+		go func() {
+			ok, err := latch.Await(ctx, time.Minute)
+			if ok {
+				t.Error("expected await not to report zero")
+			}
+			done <- err
+		}()
+		waitSubscribers(t, rdb, "{job}:latch", 1)
+
+		// 计数未归零时 latch 到期，等待者在剩余有效期后重新查询
+		rdb.Clock().Advance(time.Second)
+		select {
+		case err := <-done:
+			if !errors.Is(err, redislock.ErrLatchExpired) {
+				t.Errorf("expected ErrLatchExpired, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected waiter to notice the expiry")
+		}
+	})
+
+	t.Run("未设置", func(t *testing.T) {
+		latch := redislock.NewCountDownLatch(New(), "job", time.Hour)
+		if ok, err := latch.Await(ctx, time.Second); !errors.Is(err, redislock.ErrLatchExpired) || ok {
+			t.Errorf("expected ErrLatchExpired, got %v, %v", ok, err)
+		}
+		if count, err := latch.GetCount(ctx); err != nil || count != 0 {
+			t.Errorf("expected count 0, got %d, %v", count, err)
+		}
+	})
+
+	t.Run("无效的有效期", func(t *testing.T) {
+		rdb := New()
+		for _, latch := range []*redislock.CountDownLatch{
+			redislock.NewCountDownLatch(rdb, "job", 0),
+			redislock.NewClient(rdb).NewCountDownLatch("job", -time.Second),
+			redislock.NewCountDownLatch(rdb, "job", 500*time.Microsecond),
+		} {
+			if _, err := latch.TrySetCount(ctx, 1); !errors.Is(err, redislock.ErrInvalidTTL) {
+				t.Errorf("TrySetCount: expected ErrInvalidTTL, got %v", err)
+			}
+			if err := latch.CountDown(ctx); !errors.Is(err, redislock.ErrInvalidTTL) {
+				t.Errorf("CountDown: expected ErrInvalidTTL, got %v", err)
+			}
+			if _, err := latch.GetCount(ctx); !errors.Is(err, redislock.ErrInvalidTTL) {
+				t.Errorf("GetCount: expected ErrInvalidTTL, got %v", err)
+			}
+			if _, err := latch.Await(ctx, time.Second); !errors.Is(err, redislock.ErrInvalidTTL) {
+				t.Errorf("Await: expected ErrInvalidTTL, got %v", err)
+			}
+		}
+		if len(rdb.Keys()) != 0 {
+			t.Errorf("expected no redis calls, got keys %v", rdb.Keys())
+		}
+	})
+
+	t.Run("不支持订阅时轮询", func(t *testing.T) {
+		rdb := New()
+		latch := redislock.NewCountDownLatch(evalOnly{rdb}, "job", time.Hour)
+		_, _ = latch.TrySetCount(ctx, 1)
+
+		done := make(chan bool, 1)
+		go func() {
+			ok, _ := latch.Await(ctx, time.Minute)
+			done <- ok
+		}()
+		time.Sleep(20 * time.Millisecond)
+		_ = latch.CountDown(ctx)
+
+		select {
+		case ok := <-done:
+			if !ok {
+				t.Error("expected await to report zero")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected waiter to poll the count")
+		}
+	})
+
+	t.Run("客户端默认配置", func(t *testing.T) {
+		rdb := New()
+		client := redislock.NewClient(rdb, redislock.WithKeyPrefix("batch:"))
+		latch := client.NewCountDownLatch("job", time.Hour)
+		if _, err := latch.TrySetCount(ctx, 1); err != nil {
+			t.Fatalf("try set count: %v", err)
+		}
+		if !rdb.Exists("{batch:job}:latch") {
+			t.Errorf("expected prefixed latch key, got %v", rdb.Keys())
+		}
+	})
+}
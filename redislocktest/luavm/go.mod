@@ -0,0 +1,12 @@
+module github.com/jefferyjob/go-redislock/redislocktest/luavm
+
+go 1.21
+
+replace github.com/jefferyjob/go-redislock => ../..
+
+require (
+	github.com/jefferyjob/go-redislock v1.7.0-beta
+	github.com/yuin/gopher-lua v1.1.1
+)
+
+require github.com/google/uuid v1.6.0 // indirect
@@ -0,0 +1,430 @@
+// Package luavm runs the embedded Lua scripts of go-redislock in a Go-embedded Lua VM.
+//
+// luavm 在内嵌的 Lua 虚拟机（gopher-lua，与 Redis 一致为 Lua 5.1）中执行真实的脚本文本，
+// redis.call / redis.pcall 被转发到 redislocktest 的内存键空间。
+// 与 redislocktest.Redis 不同，它不依赖脚本的 Go 等价实现，而是直接执行 lua/*.lua，
+// 因此修改脚本后无需启动 Redis 即可在 CI 中进行单元测试。
+// Redis 也实现了 RedisFunctionInter，可在不启动 Redis 7 的情况下测试 FCALL 执行路径。
+package luavm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/jefferyjob/go-redislock/redislocktest"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Redis 使用 Lua 虚拟机执行脚本的 RedisInter 实现
+type Redis struct {
+	mu        sync.Mutex // 与 Redis 一致，脚本串行执行
+	store     *redislocktest.Redis
+	protos    map[string]*lua.FunctionProto
+	libraries map[string]*lua.FunctionProto // 函数库名称 -> 函数库代码
+	functions map[string]string             // 函数名称 -> 函数库名称
+}
+
+// New 创建 Redis，使用新的内存键空间
+func New() *Redis {
+	return NewWithStore(redislocktest.New())
+}
+
+// NewWithStore 创建 Redis，脚本中的命令在指定的内存键空间上执行
+func NewWithStore(store *redislocktest.Redis) *Redis {
+	return &Redis{
+		store:     store,
+		protos:    make(map[string]*lua.FunctionProto),
+		libraries: make(map[string]*lua.FunctionProto),
+		functions: make(map[string]string),
+	}
+}
+
+// Store 返回脚本使用的内存键空间
+func (r *Redis) Store() *redislocktest.Redis {
+	return r.store
+}
+
+// Clock 返回内存键空间使用的假时钟
+func (r *Redis) Clock() *redislocktest.Clock {
+	return r.store.Clock()
+}
+
+// Eval 在 Lua 虚拟机中执行脚本
+func (r *Redis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) redislock.RedisCmd {
+	if err := ctx.Err(); err != nil {
+		return redislocktest.NewCmd(nil, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	proto, err := r.compile(script)
+	if err != nil {
+		return redislocktest.NewCmd(nil, err)
+	}
+
+	return redislocktest.NewCmd(r.run(ctx, proto, keys, args))
+}
+
+// compile 编译脚本，编译结果按脚本内容缓存
+func (r *Redis) compile(script string) (*lua.FunctionProto, error) {
+	if proto, ok := r.protos[script]; ok {
+		return proto, nil
+	}
+
+	proto, err := compileChunk(script, "@user_script")
+	if err != nil {
+		return nil, fmt.Errorf("ERR Error compiling script: %w", err)
+	}
+
+	r.protos[script] = proto
+	return proto, nil
+}
+
+func compileChunk(code, name string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(code), name)
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, name)
+}
+
+func (r *Redis) run(ctx context.Context, proto *lua.FunctionProto, keys []string, args []interface{}) (interface{}, error) {
+	L := newState()
+	defer L.Close()
+	L.SetContext(ctx)
+
+	L.SetGlobal("KEYS", keysTable(L, keys))
+	L.SetGlobal("ARGV", argvTable(L, args))
+	L.SetGlobal("redis", r.redisModule(ctx, L))
+
+	L.Push(L.NewFunctionFromProto(proto))
+	if err := L.PCall(0, 1, nil); err != nil {
+		return nil, scriptError(err)
+	}
+
+	return fromLua(L.Get(-1))
+}
+
+// FunctionLoad 加载函数库，语义与 FUNCTION LOAD REPLACE 一致
+func (r *Redis) FunctionLoad(ctx context.Context, code string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// 第一行为元数据：#!lua name=<library>
+	header, body, _ := strings.Cut(code, "\n")
+	library, ok := strings.CutPrefix(strings.TrimSpace(header), "#!lua name=")
+	if !ok || library == "" {
+		return errors.New("ERR Missing library metadata")
+	}
+
+	proto, err := compileChunk(body, "@user_function")
+	if err != nil {
+		return fmt.Errorf("ERR Error compiling function: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 加载阶段只允许注册函数
+	L := newState()
+	defer L.Close()
+	registered := make(map[string]*lua.LFunction)
+	mod := L.NewTable()
+	mod.RawSetString("register_function", L.NewFunction(registerFunction(registered)))
+	L.SetGlobal("redis", mod)
+	L.Push(L.NewFunctionFromProto(proto))
+	if err = L.PCall(0, 0, nil); err != nil {
+		return scriptError(err)
+	}
+	if len(registered) == 0 {
+		return errors.New("ERR No functions registered")
+	}
+
+	for name := range registered {
+		if owner, ok := r.functions[name]; ok && owner != library {
+			return fmt.Errorf("ERR Function %s already exists", name)
+		}
+	}
+	for name, owner := range r.functions {
+		if owner == library {
+			delete(r.functions, name)
+		}
+	}
+	for name := range registered {
+		r.functions[name] = library
+	}
+	r.libraries[library] = proto
+	return nil
+}
+
+// FCall 调用已加载函数库中的函数
+func (r *Redis) FCall(ctx context.Context, function string, keys []string, args ...interface{}) redislock.RedisCmd {
+	if err := ctx.Err(); err != nil {
+		return redislocktest.NewCmd(nil, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	library, ok := r.functions[function]
+	if !ok {
+		return redislocktest.NewCmd(nil, errors.New("ERR Function not found"))
+	}
+
+	L := newState()
+	defer L.Close()
+	L.SetContext(ctx)
+
+	registered := make(map[string]*lua.LFunction)
+	mod := r.redisModule(ctx, L)
+	mod.RawSetString("register_function", L.NewFunction(registerFunction(registered)))
+	L.SetGlobal("redis", mod)
+	L.Push(L.NewFunctionFromProto(r.libraries[library]))
+	if err := L.PCall(0, 0, nil); err != nil {
+		return redislocktest.NewCmd(nil, scriptError(err))
+	}
+
+	L.Push(registered[function])
+	L.Push(keysTable(L, keys))
+	L.Push(argvTable(L, args))
+	if err := L.PCall(2, 1, nil); err != nil {
+		return redislocktest.NewCmd(nil, scriptError(err))
+	}
+	return redislocktest.NewCmd(fromLua(L.Get(-1)))
+}
+
+// registerFunction redis.register_function，支持 (name, callback) 与 {function_name=, callback=} 两种形式
+func registerFunction(registered map[string]*lua.LFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		var (
+			name     lua.LValue
+			callback lua.LValue
+		)
+		if tbl, ok := L.Get(1).(*lua.LTable); ok {
+			name, callback = tbl.RawGetString("function_name"), tbl.RawGetString("callback")
+		} else {
+			name, callback = L.Get(1), L.Get(2)
+		}
+
+		fn, ok := callback.(*lua.LFunction)
+		if name.Type() != lua.LTString || !ok {
+			L.RaiseError("wrong arguments given to redis.register_function")
+			return 0
+		}
+		if _, ok = registered[name.String()]; ok {
+			L.RaiseError("Function already exists in the library")
+			return 0
+		}
+		registered[name.String()] = fn
+		return 0
+	}
+}
+
+func keysTable(L *lua.LState, keys []string) *lua.LTable {
+	tbl := L.NewTable()
+	for _, key := range keys {
+		tbl.Append(lua.LString(key))
+	}
+	return tbl
+}
+
+func argvTable(L *lua.LState, args []interface{}) *lua.LTable {
+	tbl := L.NewTable()
+	for _, arg := range args {
+		tbl.Append(lua.LString(toString(arg)))
+	}
+	return tbl
+}
+
+// scriptError 将脚本运行错误转换为 Redis 错误回复
+func scriptError(err error) error {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) {
+		if tbl, ok := apiErr.Object.(*lua.LTable); ok {
+			if msg, ok := tbl.RawGetString("err").(lua.LString); ok {
+				return errors.New(string(msg))
+			}
+		}
+		return fmt.Errorf("ERR Error running script: %s", apiErr.Object.String())
+	}
+	return err
+}
+
+// newState 创建虚拟机，仅加载 Redis 脚本环境中可用的标准库
+func newState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	return L
+}
+
+// redisModule 脚本中的 redis 全局对象
+func (r *Redis) redisModule(ctx context.Context, L *lua.LState) *lua.LTable {
+	mod := L.NewTable()
+	L.SetFuncs(mod, map[string]lua.LGFunction{
+		"call": func(L *lua.LState) int {
+			res, err := r.call(ctx, L)
+			if err != nil {
+				L.RaiseError("%s", err.Error())
+				return 0
+			}
+			L.Push(res)
+			return 1
+		},
+		"pcall": func(L *lua.LState) int {
+			res, err := r.call(ctx, L)
+			if err != nil {
+				L.Push(errorReply(L, err.Error()))
+				return 1
+			}
+			L.Push(res)
+			return 1
+		},
+		"error_reply": func(L *lua.LState) int {
+			L.Push(errorReply(L, L.CheckString(1)))
+			return 1
+		},
+		"status_reply": func(L *lua.LState) int {
+			tbl := L.NewTable()
+			tbl.RawSetString("ok", lua.LString(L.CheckString(1)))
+			L.Push(tbl)
+			return 1
+		},
+		"log": func(L *lua.LState) int {
+			return 0
+		},
+	})
+	for name, level := range map[string]int{"LOG_DEBUG": 0, "LOG_VERBOSE": 1, "LOG_NOTICE": 2, "LOG_WARNING": 3} {
+		mod.RawSetString(name, lua.LNumber(level))
+	}
+	return mod
+}
+
+// call 执行 redis.call / redis.pcall 的命令
+func (r *Redis) call(ctx context.Context, L *lua.LState) (lua.LValue, error) {
+	n := L.GetTop()
+	if n == 0 {
+		return nil, errors.New("Please specify at least one argument for this redis lib call")
+	}
+
+	args := make([]interface{}, n)
+	for i := 1; i <= n; i++ {
+		switch v := L.Get(i).(type) {
+		case lua.LString:
+			args[i-1] = string(v)
+		case lua.LNumber:
+			// 与 Redis 一致，数字参数以 %.17g 转为字符串
+			args[i-1] = strconv.FormatFloat(float64(v), 'g', 17, 64)
+		default:
+			return nil, errors.New("Lua redis lib command arguments must be strings or integers")
+		}
+	}
+
+	res, err := r.store.Do(ctx, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return toLua(L, res), nil
+}
+
+func errorReply(L *lua.LState, msg string) *lua.LTable {
+	tbl := L.NewTable()
+	tbl.RawSetString("err", lua.LString(msg))
+	return tbl
+}
+
+// toLua 将 Redis 回复转换为 Lua 值，规则与 Redis 一致：空回复转为 false
+func toLua(L *lua.LState, v interface{}) lua.LValue {
+	switch val := v.(type) {
+	case nil:
+		return lua.LFalse
+	case int64:
+		return lua.LNumber(val)
+	case string:
+		return lua.LString(val)
+	case []interface{}:
+		tbl := L.NewTable()
+		for _, item := range val {
+			tbl.Append(toLua(L, item))
+		}
+		return tbl
+	default:
+		return lua.LString(fmt.Sprint(val))
+	}
+}
+
+// fromLua 将脚本返回值转换为 Redis 回复，规则与 Redis 一致：
+// 数字截断为整数，true 为 1，false 为空回复，数组在第一个 nil 处截断
+func fromLua(v lua.LValue) (interface{}, error) {
+	switch val := v.(type) {
+	case lua.LNumber:
+		return int64(val), nil
+	case lua.LString:
+		return string(val), nil
+	case lua.LBool:
+		if val {
+			return int64(1), nil
+		}
+		return nil, nil
+	case *lua.LTable:
+		if msg, ok := val.RawGetString("err").(lua.LString); ok {
+			return nil, errors.New(string(msg))
+		}
+		if status, ok := val.RawGetString("ok").(lua.LString); ok {
+			return string(status), nil
+		}
+		res := make([]interface{}, 0, val.Len())
+		for i := 1; ; i++ {
+			item := val.RawGetInt(i)
+			if item == lua.LNil {
+				break
+			}
+			// 数组中的错误回复作为元素保留
+			converted, err := fromLua(item)
+			if err != nil {
+				converted = err
+			}
+			res = append(res, converted)
+		}
+		return res, nil
+	default:
+		return nil, nil
+	}
+}
+
+func toString(v interface{}) string {
+	switch a := v.(type) {
+	case string:
+		return a
+	case []byte:
+		return string(a)
+	case float64:
+		return strconv.FormatFloat(a, 'f', -1, 64)
+	case bool:
+		if a {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(a)
+	}
+}
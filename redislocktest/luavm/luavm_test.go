@@ -0,0 +1,500 @@
+package luavm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/jefferyjob/go-redislock/redislocktest"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// dual 同时在 Lua 虚拟机与 redislocktest 的 Go 实现上执行脚本，并比较两者结果
+type dual struct {
+	t    *testing.T
+	lua  *Redis
+	fake *redislocktest.Redis
+}
+
+func newDual(t *testing.T) *dual {
+	clock := redislocktest.NewClock(time.Now())
+	return &dual{
+		t:    t,
+		lua:  NewWithStore(redislocktest.NewWithClock(clock)),
+		fake: redislocktest.NewWithClock(clock),
+	}
+}
+
+func (d *dual) Eval(ctx context.Context, script string, keys []string, args ...interface{}) redislock.RedisCmd {
+	got, gotErr := d.lua.Eval(ctx, script, keys, args...).Result()
+	want, wantErr := d.fake.Eval(ctx, script, keys, args...).Result()
+
+	if !reflect.DeepEqual(got, want) || (gotErr == nil) != (wantErr == nil) {
+		d.t.Errorf("lua and fake disagree on %s %v: lua=(%v, %v) fake=(%v, %v)",
+			scriptName(script), keys, got, gotErr, want, wantErr)
+	}
+	return redislocktest.NewCmd(got, gotErr)
+}
+
+// dualFunctions 与 dual 相同，但 Lua 一侧通过函数库（FCALL）执行脚本
+type dualFunctions struct {
+	*dual
+}
+
+func (d dualFunctions) FunctionLoad(ctx context.Context, code string) error {
+	return d.lua.FunctionLoad(ctx, code)
+}
+
+func (d dualFunctions) FCall(ctx context.Context, function string, keys []string, args ...interface{}) redislock.RedisCmd {
+	got, gotErr := d.lua.FCall(ctx, function, keys, args...).Result()
+
+	library, _ := redislock.FunctionLibrary()
+	script, ok := redislock.Scripts()[strings.TrimPrefix(function, library+"_")]
+	if !ok {
+		// 版本检查等非脚本函数
+		return redislocktest.NewCmd(got, gotErr)
+	}
+
+	want, wantErr := d.fake.Eval(ctx, script, keys, args...).Result()
+	if !reflect.DeepEqual(got, want) || (gotErr == nil) != (wantErr == nil) {
+		d.t.Errorf("function and fake disagree on %s %v: lua=(%v, %v) fake=(%v, %v)",
+			function, keys, got, gotErr, want, wantErr)
+	}
+	return redislocktest.NewCmd(got, gotErr)
+}
+
+func (d *dual) advance(dur time.Duration) {
+	d.lua.Clock().Advance(dur)
+}
+
+func scriptName(script string) string {
+	for name, src := range redislock.Scripts() {
+		if src == script {
+			return name
+		}
+	}
+	return "script"
+}
+
+// 每个内置脚本都能被编译
+func TestCompileScripts(t *testing.T) {
+	rdb := New()
+	for name, src := range redislock.Scripts() {
+		if _, err := rdb.compile(src); err != nil {
+			t.Errorf("compile %s: %v", name, err)
+		}
+	}
+}
+
+// 脚本返回值按 Redis 规则转换
+func TestEvalReply(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		script  string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "整数", script: "return 3.7", want: int64(3)},
+		{name: "字符串", script: "return 'ok'", want: "ok"},
+		{name: "空回复", script: "return false", want: nil},
+		{name: "true", script: "return true", want: int64(1)},
+		{name: "数组", script: "return {1, 'a', {2}}", want: []interface{}{int64(1), "a", []interface{}{int64(2)}}},
+		{name: "数组在nil处截断", script: "return {1, nil, 3}", want: []interface{}{int64(1)}},
+		{name: "状态回复", script: "return redis.status_reply('PONG')", want: "PONG"},
+		{name: "错误回复", script: "return redis.error_reply('ERR boom')", wantErr: true},
+		{name: "命令错误", script: "return redis.call('NOPE')", wantErr: true},
+		{name: "pcall捕获错误", script: "return redis.pcall('NOPE')['err'] ~= nil", want: int64(1)},
+		{name: "空回复转为false", script: "return redis.call('GET', KEYS[1]) == false", want: int64(1)},
+		{name: "数字参数", script: "redis.call('SET', KEYS[1], 1e12) return redis.call('GET', KEYS[1])", want: "1000000000000"},
+		{name: "语法错误", script: "return (", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New().Eval(ctx, tt.script, []string{"key"}).Result()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := redislock.New(New(), "key").Lock(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+}
+
+// 通过锁 API 执行真实脚本，并与 Go 实现逐次比对
+func TestScripts(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func(d redislock.RedisInter, advance func(time.Duration))
+	}{
+		{
+			name: "普通锁",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				a := redislock.New(d, "key", redislock.WithToken("a"))
+				b := redislock.New(d, "key", redislock.WithToken("b"))
+				_ = a.Lock(ctx)
+				_ = a.Lock(ctx)
+				_ = b.Lock(ctx)
+				_ = b.UnLock(ctx)
+				_ = b.Renew(ctx)
+				advance(2 * time.Second)
+				_ = a.Renew(ctx)
+				_ = a.UnLock(ctx)
+				_ = a.UnLock(ctx)
+				_ = a.UnLock(ctx)
+				_ = b.Lock(ctx)
+				advance(6 * time.Second)
+				_ = b.UnLock(ctx)
+				_ = b.Renew(ctx)
+				_ = a.Lock(ctx)
+			},
+		},
+		{
+			name: "公平锁",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				lock := redislock.New(d, "key", redislock.WithRequestTimeout(3*time.Second), redislock.WithMaxQueueLength(3))
+				_ = lock.FairLock(ctx, "r1")
+				advance(time.Second)
+				_ = lock.FairLock(ctx, "r2")
+				advance(time.Second)
+				_ = lock.FairLock(ctx, "r3")
+				_ = lock.FairLock(ctx, "r4")
+				_ = lock.FairRenew(ctx, "r1")
+				_ = lock.FairRenew(ctx, "r2")
+				_ = lock.FairCancel(ctx, "r1")
+				_ = lock.FairCancel(ctx, "r2")
+				_ = lock.FairUnLock(ctx, "r1")
+				_ = lock.FairLock(ctx, "r3")
+				advance(2 * time.Second)
+				_ = lock.FairLock(ctx, "r3")
+				advance(6 * time.Second)
+				_ = lock.FairRenew(ctx, "r3")
+				_ = lock.FairLock(ctx, "r4")
+			},
+		},
+		{
+			name: "优先级公平锁",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				lock := redislock.New(d, "key", redislock.WithPriorityAging(time.Second))
+				_ = lock.PriorityFairLock(ctx, "holder", 0)
+				_ = lock.PriorityFairLock(ctx, "low", -2)
+				advance(500 * time.Millisecond)
+				_ = lock.PriorityFairLock(ctx, "high", 1)
+				advance(3 * time.Second)
+				_ = lock.PriorityFairLock(ctx, "mid", 0)
+				_ = lock.FairUnLock(ctx, "holder")
+				_ = lock.PriorityFairLock(ctx, "low", -2)
+				_ = lock.PriorityFairLock(ctx, "high", 1)
+				_ = lock.FairCancel(ctx, "mid")
+				_ = lock.FairUnLock(ctx, "high")
+				_ = lock.PriorityFairLock(ctx, "low", -2)
+			},
+		},
+		{
+			name: "读写锁",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				a := redislock.New(d, "key", redislock.WithToken("a"))
+				b := redislock.New(d, "key", redislock.WithToken("b"))
+				_ = a.RLock(ctx)
+				_ = a.RLock(ctx)
+				_ = b.RLock(ctx)
+				_ = a.WLock(ctx)
+				_ = b.RUnLock(ctx)
+				_ = a.WLock(ctx)
+				_ = a.WLock(ctx)
+				_ = b.RLock(ctx)
+				_ = b.WLock(ctx)
+				_ = a.WRenew(ctx)
+				_ = a.RRenew(ctx)
+				_ = a.WUnLock(ctx)
+				_ = a.WUnLock(ctx)
+				_ = a.RUnLock(ctx)
+				_ = a.RUnLock(ctx)
+				_ = a.RUnLock(ctx)
+				_ = b.WLock(ctx)
+				advance(6 * time.Second)
+				_ = b.WUnLock(ctx)
+				_ = b.WRenew(ctx)
+				_ = a.RLock(ctx)
+			},
+		},
+		{
+			name: "解锁去重",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				scripts := redislock.Scripts()
+				for _, key := range []string{"key", "a{tag}b", "a{}b"} {
+					_ = redislock.New(d, key, redislock.WithToken("a")).Lock(ctx)
+					_ = redislock.New(d, key, redislock.WithToken("a")).Lock(ctx)
+					_ = d.Eval(ctx, scripts["reentrantUnLock"], []string{key}, "a", "r1", 60000)
+					_ = d.Eval(ctx, scripts["reentrantUnLock"], []string{key}, "a", "r1", 60000)
+					_ = d.Eval(ctx, scripts["reentrantUnLock"], []string{key}, "a", "", 60000)
+
+					rw := "rw:" + key
+					_ = redislock.New(d, rw, redislock.WithToken("a")).RLock(ctx)
+					_ = redislock.New(d, rw, redislock.WithToken("a")).RLock(ctx)
+					_ = redislock.New(d, rw, redislock.WithToken("b")).RLock(ctx)
+					_ = d.Eval(ctx, scripts["readUnLock"], []string{rw}, "a", "r2", 60000)
+					_ = d.Eval(ctx, scripts["readUnLock"], []string{rw}, "a", "r2", 60000)
+					_ = d.Eval(ctx, scripts["readUnLock"], []string{rw}, "a", "r3", 60000)
+					_ = d.Eval(ctx, scripts["readUnLock"], []string{rw}, "a", "r3", 60000)
+					_ = d.Eval(ctx, scripts["readUnLock"], []string{rw}, "b", "r4", 60000)
+
+					_ = redislock.New(d, rw, redislock.WithToken("a")).WLock(ctx)
+					_ = redislock.New(d, rw, redislock.WithToken("a")).WLock(ctx)
+					_ = d.Eval(ctx, scripts["writeUnLock"], []string{rw}, "a", "w1", 60000)
+					_ = d.Eval(ctx, scripts["writeUnLock"], []string{rw}, "a", "w1", 60000)
+					_ = redislock.New(d, rw, redislock.WithToken("b")).WLock(ctx)
+				}
+				// 解锁记录过期后不再去重
+				_ = redislock.New(d, "exp", redislock.WithToken("a")).WLock(ctx)
+				_ = redislock.New(d, "exp", redislock.WithToken("a")).WLock(ctx)
+				_ = redislock.New(d, "exp", redislock.WithToken("a")).WLock(ctx)
+				_ = d.Eval(ctx, scripts["writeUnLock"], []string{"exp"}, "a", "w2", 1000)
+				advance(500 * time.Millisecond)
+				_ = d.Eval(ctx, scripts["writeUnLock"], []string{"exp"}, "a", "w2", 1000)
+				advance(time.Second)
+				_ = d.Eval(ctx, scripts["writeUnLock"], []string{"exp"}, "a", "w2", 1000)
+			},
+		},
+		{
+			name: "联锁",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				scripts := redislock.Scripts()
+				keys := []string{"k1", "k2"}
+				_ = d.Eval(ctx, scripts["multiLock"], keys[:1], "a", 5000)
+				_ = d.Eval(ctx, scripts["multiLock"], keys[:1], "b", 5000)
+				_ = d.Eval(ctx, scripts["multiRenew"], keys[:1], "a", 5000)
+				_ = d.Eval(ctx, scripts["multiRenew"], keys[:1], "b", 5000)
+				_ = d.Eval(ctx, scripts["multiUnLock"], keys[:1], "b")
+				_ = d.Eval(ctx, scripts["multiUnLock"], keys[:1], "a")
+				_ = d.Eval(ctx, scripts["multiLock"], keys[1:], "b", 5000)
+			},
+		},
+		{
+			name: "批量续期",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				batch := redislock.Scripts()["batchRenew"]
+				_ = redislock.New(d, "r", redislock.WithToken("a")).Lock(ctx)
+				_ = redislock.New(d, "f").FairLock(ctx, "a")
+				_ = redislock.New(d, "rw", redislock.WithToken("a")).RLock(ctx)
+				_ = redislock.New(d, "w", redislock.WithToken("a")).WLock(ctx)
+				keys := []string{"r", "f", "rw", "w", "missing"}
+				_ = d.Eval(ctx, batch, keys,
+					"reentrant", "a", 9000, "fair", "a", 9000, "read", "a", 9000, "write", "a", 9000, "fair", "a", 9000)
+				_ = d.Eval(ctx, batch, keys[:4],
+					"reentrant", "b", 9000, "fair", "b", 9000, "read", "b", 9000, "write", "b", 9000)
+				_ = d.Eval(ctx, batch, keys[2:3], "write", "a", 9000)
+				advance(6 * time.Second)
+				_ = d.Eval(ctx, batch, keys[:4],
+					"reentrant", "a", 9000, "fair", "a", 9000, "read", "a", 9000, "write", "a", 9000)
+				_ = d.Eval(ctx, batch, keys[:1], "unknown", "a", 9000)
+			},
+		},
+		{
+			name: "singleflight 共享结果",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				get, set := redislock.Scripts()["singleflightGet"], redislock.Scripts()["singleflightSet"]
+				_ = d.Eval(ctx, get, []string{"s"})
+				_ = d.Eval(ctx, redislock.Scripts()["singleflightNotify"], []string{"s"})
+				_ = d.Eval(ctx, set, []string{"s"}, "a", "v:x", 9000)
+				_ = redislock.New(d, "s", redislock.WithToken("a")).Lock(ctx)
+				_ = d.Eval(ctx, set, []string{"s"}, "b", "v:x", 9000)
+				_ = d.Eval(ctx, set, []string{"s"}, "a", "v:x", 9000)
+				_ = d.Eval(ctx, get, []string{"s"})
+				advance(6 * time.Second)
+				_ = d.Eval(ctx, get, []string{"s"})
+				_ = d.Eval(ctx, set, []string{"s"}, "a", "e:boom", 1000)
+				advance(4 * time.Second)
+				_ = d.Eval(ctx, get, []string{"s"})
+			},
+		},
+		{
+			name: "选主任期",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				scripts := redislock.Scripts()
+				elect, leader, resign := scripts["electionElect"], scripts["electionLeader"], scripts["electionResign"]
+				_ = d.Eval(ctx, leader, []string{"e"})
+				_ = d.Eval(ctx, elect, []string{"e"}, "a", "meta-a")
+				_ = redislock.New(d, "e", redislock.WithToken("a")).Lock(ctx)
+				_ = d.Eval(ctx, leader, []string{"e"})
+				_ = d.Eval(ctx, elect, []string{"e"}, "b", "meta-b")
+				_ = d.Eval(ctx, elect, []string{"e"}, "a", "meta-a")
+				_ = d.Eval(ctx, leader, []string{"e"})
+				_ = d.Eval(ctx, resign, []string{"e"}, "b")
+				_ = d.Eval(ctx, resign, []string{"e"}, "a")
+				_ = d.Eval(ctx, leader, []string{"e"})
+				advance(6 * time.Second)
+				_ = d.Eval(ctx, leader, []string{"e"})
+				_ = redislock.New(d, "e", redislock.WithToken("b")).Lock(ctx)
+				_ = d.Eval(ctx, elect, []string{"e"}, "b", "")
+				_ = d.Eval(ctx, leader, []string{"e"})
+			},
+		},
+		{
+			name: "CountDownLatch",
+			run: func(d redislock.RedisInter, advance func(time.Duration)) {
+				scripts := redislock.Scripts()
+				set, countDown, get := scripts["latchTrySet"], scripts["latchCountDown"], scripts["latchGet"]
+				_ = d.Eval(ctx, get, []string{"l"})
+				_ = d.Eval(ctx, countDown, []string{"l"}, 9000)
+				_ = d.Eval(ctx, set, []string{"l"}, 2, 9000)
+				_ = d.Eval(ctx, set, []string{"l"}, 5, 9000)
+				_ = d.Eval(ctx, get, []string{"l"})
+				advance(time.Second)
+				_ = d.Eval(ctx, countDown, []string{"l"}, 9000)
+				_ = d.Eval(ctx, get, []string{"l"})
+				_ = d.Eval(ctx, countDown, []string{"l"}, 9000)
+				_ = d.Eval(ctx, get, []string{"l"})
+				_ = d.Eval(ctx, set, []string{"l"}, 3, 1000)
+				advance(2 * time.Second)
+				_ = d.Eval(ctx, get, []string{"l"})
+				_ = d.Eval(ctx, countDown, []string{"l"}, 1000)
+				// 归零后保留完成标记，可重新设置
+				_ = d.Eval(ctx, set, []string{"d"}, 1, 9000)
+				_ = d.Eval(ctx, countDown, []string{"d"}, 9000)
+				_ = d.Eval(ctx, countDown, []string{"d"}, 9000)
+				_ = d.Eval(ctx, get, []string{"d"})
+				_ = d.Eval(ctx, set, []string{"d"}, 2, 9000)
+				_ = d.Eval(ctx, countDown, []string{"d"}, 9000)
+				_ = d.Eval(ctx, countDown, []string{"d"}, 9000)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, mode := range []string{"EVAL", "FCALL"} {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				d := newDual(t)
+				var rdb redislock.RedisInter = d
+				if mode == "FCALL" {
+					rdb = dualFunctions{d}
+				}
+				tt.run(rdb, d.advance)
+
+				// 最终键空间保持一致
+				got, want := d.lua.Store().Keys(), d.fake.Keys()
+				sort.Strings(got)
+				sort.Strings(want)
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("keyspace differs: lua=%v fake=%v", got, want)
+				}
+			})
+		}
+	}
+}
+
+// noFunctions 模拟不支持 Redis Functions 的服务端（Redis 7.0 以下）
+type noFunctions struct {
+	*Redis
+}
+
+func (r noFunctions) FunctionLoad(ctx context.Context, code string) error {
+	return errors.New("ERR unknown command 'FUNCTION', with args beginning with: 'LOAD'")
+}
+
+func (r noFunctions) FCall(ctx context.Context, function string, keys []string, args ...interface{}) redislock.RedisCmd {
+	return redislocktest.NewCmd(nil, errors.New("ERR unknown command 'FCALL', with args beginning with: "))
+}
+
+func TestFunctions(t *testing.T) {
+	ctx := context.Background()
+	library, _ := redislock.FunctionLibrary()
+
+	t.Run("自动加载函数库", func(t *testing.T) {
+		rdb := New()
+		lock := redislock.New(rdb, "key")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		if _, ok := rdb.libraries[library]; !ok {
+			t.Fatalf("library %s not loaded", library)
+		}
+		if err := lock.UnLock(ctx); err != nil {
+			t.Errorf("unlock: %v", err)
+		}
+	})
+
+	t.Run("函数库丢失后重新加载", func(t *testing.T) {
+		rdb := New()
+		lock := redislock.New(rdb, "key")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+
+		// 模拟 FUNCTION FLUSH
+		rdb.functions = make(map[string]string)
+		rdb.libraries = make(map[string]*lua.FunctionProto)
+
+		if err := lock.UnLock(ctx); err != nil {
+			t.Fatalf("unlock: %v", err)
+		}
+		if _, ok := rdb.libraries[library]; !ok {
+			t.Errorf("library %s not reloaded", library)
+		}
+	})
+
+	t.Run("服务端不支持时回退为EVAL", func(t *testing.T) {
+		rdb := noFunctions{New()}
+		lock := redislock.New(rdb, "key")
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		if err := redislock.New(rdb, "key").Lock(ctx); !errors.Is(err, redislock.ErrLockFailed) {
+			t.Errorf("expected error %v, got %v", redislock.ErrLockFailed, err)
+		}
+		if err := lock.UnLock(ctx); err != nil {
+			t.Errorf("unlock: %v", err)
+		}
+	})
+
+	t.Run("禁用Functions", func(t *testing.T) {
+		rdb := New()
+		lock := redislock.New(rdb, "key", redislock.WithFunctions(false))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		if len(rdb.libraries) != 0 {
+			t.Errorf("expected no library loaded, got %d", len(rdb.libraries))
+		}
+	})
+
+	t.Run("函数名冲突", func(t *testing.T) {
+		rdb := New()
+		code := "#!lua name=a\nredis.register_function('f', function() return 1 end)"
+		if err := rdb.FunctionLoad(ctx, code); err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if err := rdb.FunctionLoad(ctx, code); err != nil {
+			t.Errorf("replace: %v", err)
+		}
+		if err := rdb.FunctionLoad(ctx, strings.Replace(code, "name=a", "name=b", 1)); err == nil {
+			t.Error("expected error for duplicate function")
+		}
+		if err := rdb.FunctionLoad(ctx, "return 1"); err == nil {
+			t.Error("expected error for missing metadata")
+		}
+		if got, err := rdb.FCall(ctx, "f", nil).Int64(); err != nil || got != 1 {
+			t.Errorf("expected 1, got %d (%v)", got, err)
+		}
+	})
+}
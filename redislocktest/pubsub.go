@@ -0,0 +1,76 @@
+package redislocktest
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriberBuffer 每个订阅缓冲的消息数，缓冲已满时丢弃新消息（与 RedisPubSubInter 的约定一致）
+const subscriberBuffer = 16
+
+// Subscribe 实现 redislock.RedisPubSubInter：订阅 channel，脚本或 Do 执行 PUBLISH 时收到消息
+func (r *Redis) Subscribe(ctx context.Context, channel string) (<-chan string, func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	ch := make(chan string, subscriberBuffer)
+
+	r.mu.Lock()
+	r.ks.subscribe(channel, ch)
+	r.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.ks.unsubscribe(channel, ch)
+		})
+	}
+	return ch, unsubscribe, nil
+}
+
+// Subscribers 返回 channel 当前的订阅数，便于在测试中断言订阅已释放
+func (r *Redis) Subscribers(channel string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.ks.subs[channel])
+}
+
+func (k *keyspace) subscribe(channel string, ch chan string) {
+	if k.subs == nil {
+		k.subs = make(map[string]map[chan string]struct{})
+	}
+	if k.subs[channel] == nil {
+		k.subs[channel] = make(map[chan string]struct{})
+	}
+	k.subs[channel][ch] = struct{}{}
+}
+
+func (k *keyspace) unsubscribe(channel string, ch chan string) {
+	delete(k.subs[channel], ch)
+	if len(k.subs[channel]) == 0 {
+		delete(k.subs, channel)
+	}
+}
+
+// publish 向 channel 的订阅者发送消息，返回收到消息的订阅数
+func (k *keyspace) publish(channel, message string) int64 {
+	var n int64
+	for ch := range k.subs[channel] {
+		select {
+		case ch <- message:
+		default:
+		}
+		n++
+	}
+	return n
+}
+
+func cmdPublish(k *keyspace, args []string) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, errWrongNumber
+	}
+	return k.publish(args[0], args[1]), nil
+}
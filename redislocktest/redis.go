@@ -0,0 +1,166 @@
+// Package redislocktest provides an in-memory implementation of redislock.RedisInter for unit tests.
+//
+// redislocktest 提供 redislock.RedisInter 的纯内存实现，无需启动 Redis 即可测试锁的真实语义。
+// 它识别 go-redislock 内置的每个 Lua 脚本，并在内存中以 Go 代码实现相同的语义
+// （普通锁、公平锁、优先级公平锁、读锁、写锁、联锁）。
+// 配合可控的假时钟 Clock，可以确定性地测试锁过期、公平锁排队超时等行为。
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// ErrUnsupportedScript 执行了无法识别的脚本
+var ErrUnsupportedScript = errors.New("redislocktest: unsupported script")
+
+// scriptFunc 脚本在内存中的实现
+type scriptFunc func(k *keyspace, keys []string, args []string) (interface{}, error)
+
+// Redis 内存版 Redis，实现 redislock.RedisInter
+type Redis struct {
+	mu      sync.Mutex
+	clock   *Clock
+	ks      *keyspace
+	scripts map[string]scriptFunc
+	// WAIT 返回的确认副本数
+	replicas int64
+}
+
+// New 创建内存版 Redis，使用从当前时间开始的假时钟
+func New() *Redis {
+	return NewWithClock(NewClock(time.Now()))
+}
+
+// NewWithClock 创建使用指定假时钟的内存版 Redis
+func NewWithClock(clock *Clock) *Redis {
+	r := &Redis{
+		clock:   clock,
+		ks:      newKeyspace(clock),
+		scripts: make(map[string]scriptFunc),
+	}
+
+	// 按脚本内容识别内置脚本
+	for name, src := range redislock.Scripts() {
+		if fn, ok := builtinScripts[name]; ok {
+			r.scripts[src] = fn
+		}
+	}
+
+	return r
+}
+
+// Clock 返回该实例使用的假时钟
+func (r *Redis) Clock() *Clock {
+	return r.clock
+}
+
+// Eval 在内存中执行内置脚本的等价逻辑
+func (r *Redis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) redislock.RedisCmd {
+	if err := ctx.Err(); err != nil {
+		return &Cmd{err: err}
+	}
+
+	fn, ok := r.scripts[script]
+	if !ok {
+		return &Cmd{err: ErrUnsupportedScript}
+	}
+
+	strArgs := make([]string, len(args))
+	for i, arg := range args {
+		strArgs[i] = toString(arg)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	val, err := fn(r.ks, keys, strArgs)
+	return &Cmd{val: val, err: err}
+}
+
+// Exists 判断 key 是否存在（已过期的 key 视为不存在），便于在测试中断言
+func (r *Redis) Exists(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ks.exists(key)
+}
+
+// Keys 返回当前所有未过期的 key，便于在测试中断言
+func (r *Redis) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]string, 0, len(r.ks.data))
+	for key := range r.ks.data {
+		if r.ks.exists(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// FlushAll 清空所有数据
+func (r *Redis) FlushAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ks.data = make(map[string]*entry)
+}
+
+// Cmd 脚本执行结果，实现 redislock.RedisCmd
+// 返回值类型与 Redis 客户端一致：整数为 int64，字符串为 string，数组为 []interface{}，nil 表示空回复
+type Cmd struct {
+	val interface{}
+	err error
+}
+
+// NewCmd 使用给定的结果创建 Cmd，便于基于本包实现其他 RedisInter
+func NewCmd(val interface{}, err error) *Cmd {
+	return &Cmd{val: val, err: err}
+}
+
+func (c *Cmd) Result() (interface{}, error) {
+	return c.val, c.err
+}
+
+func (c *Cmd) Int64() (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	switch v := c.val.(type) {
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("redislocktest: cannot convert result to int: %T", c.val)
+	}
+}
+
+func toString(v interface{}) string {
+	switch a := v.(type) {
+	case string:
+		return a
+	case []byte:
+		return string(a)
+	case int:
+		return strconv.Itoa(a)
+	case int64:
+		return strconv.FormatInt(a, 10)
+	case float64:
+		return strconv.FormatFloat(a, 'f', -1, 64)
+	case bool:
+		if a {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(a)
+	}
+}
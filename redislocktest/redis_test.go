@@ -0,0 +1,499 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// 每个内置脚本都需要有内存实现
+func TestBuiltinScripts(t *testing.T) {
+	for name := range redislock.Scripts() {
+		if _, ok := builtinScripts[name]; !ok {
+			t.Errorf("script %s has no in-memory implementation", name)
+		}
+	}
+}
+
+func TestUnsupportedScript(t *testing.T) {
+	_, err := New().Eval(context.Background(), "return 1", nil).Result()
+	if !errors.Is(err, ErrUnsupportedScript) {
+		t.Errorf("expected error %v, got %v", ErrUnsupportedScript, err)
+	}
+}
+
+func TestContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := redislock.New(New(), "key").Lock(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func(rdb *Redis) error
+		want error
+	}{
+		{
+			name: "普通锁-可重入",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key", redislock.WithToken("a"))
+				if err := lock.Lock(ctx); err != nil {
+					return err
+				}
+				if err := lock.Lock(ctx); err != nil {
+					return err
+				}
+				if err := lock.UnLock(ctx); err != nil {
+					return err
+				}
+				// 仍持有一次重入计数，其他人无法加锁
+				return redislock.New(rdb, "key", redislock.WithToken("b")).Lock(ctx)
+			},
+			want: redislock.ErrLockHeld,
+		},
+		{
+			name: "普通锁-过期后他人可加锁",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key", redislock.WithToken("a"))
+				if err := lock.Lock(ctx); err != nil {
+					return err
+				}
+				rdb.Clock().Advance(6 * time.Second)
+				return redislock.New(rdb, "key", redislock.WithToken("b")).Lock(ctx)
+			},
+			want: nil,
+		},
+		{
+			name: "普通锁-续期后未过期",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key", redislock.WithToken("a"))
+				if err := lock.Lock(ctx); err != nil {
+					return err
+				}
+				rdb.Clock().Advance(4 * time.Second)
+				if err := lock.Renew(ctx); err != nil {
+					return err
+				}
+				rdb.Clock().Advance(4 * time.Second)
+				return redislock.New(rdb, "key", redislock.WithToken("b")).Lock(ctx)
+			},
+			want: redislock.ErrLockHeld,
+		},
+		{
+			name: "普通锁-过期后解锁",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key", redislock.WithToken("a"))
+				if err := lock.Lock(ctx); err != nil {
+					return err
+				}
+				rdb.Clock().Advance(6 * time.Second)
+				return lock.UnLock(ctx)
+			},
+			want: redislock.ErrLockExpired,
+		},
+		{
+			name: "普通锁-非持有者解锁",
+			run: func(rdb *Redis) error {
+				if err := redislock.New(rdb, "key", redislock.WithToken("a")).Lock(ctx); err != nil {
+					return err
+				}
+				return redislock.New(rdb, "key", redislock.WithToken("b")).UnLock(ctx)
+			},
+			want: redislock.ErrNotOwner,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run(New())
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected error %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestFairLock(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func(rdb *Redis) error
+		want error
+	}{
+		{
+			name: "公平锁-按顺序排队",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key")
+				if err := lock.FairLock(ctx, "first"); err != nil {
+					return err
+				}
+				rdb.Clock().Advance(time.Second)
+				if err := lock.FairLock(ctx, "second"); !errors.Is(err, redislock.ErrNotQueueHead) {
+					return err
+				}
+				rdb.Clock().Advance(time.Second)
+				if err := lock.FairLock(ctx, "third"); !errors.Is(err, redislock.ErrNotQueueHead) {
+					return err
+				}
+				if err := lock.FairUnLock(ctx, "first"); err != nil {
+					return err
+				}
+				// 第三个请求仍需等待第二个请求
+				if err := lock.FairLock(ctx, "third"); !errors.Is(err, redislock.ErrNotQueueHead) {
+					return err
+				}
+				return lock.FairLock(ctx, "second")
+			},
+			want: nil,
+		},
+		{
+			name: "公平锁-排队超时被清理",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key", redislock.WithRequestTimeout(2*time.Second), redislock.WithTimeout(10*time.Second))
+				if err := lock.FairLock(ctx, "holder"); err != nil {
+					return err
+				}
+				if err := lock.FairLock(ctx, "waiter"); !errors.Is(err, redislock.ErrNotQueueHead) {
+					return err
+				}
+				// 持锁者的排队记录超时被清理，但锁仍未过期
+				rdb.Clock().Advance(3 * time.Second)
+				return lock.FairLock(ctx, "waiter")
+			},
+			want: redislock.ErrLockHeld,
+		},
+		{
+			name: "公平锁-队列已满",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key", redislock.WithMaxQueueLength(1))
+				if err := lock.FairLock(ctx, "holder"); err != nil {
+					return err
+				}
+				return lock.FairLock(ctx, "waiter")
+			},
+			want: redislock.ErrQueueFull,
+		},
+		{
+			name: "公平锁-取消排队",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key")
+				if err := lock.FairLock(ctx, "holder"); err != nil {
+					return err
+				}
+				if err := lock.FairLock(ctx, "waiter"); !errors.Is(err, redislock.ErrNotQueueHead) {
+					return err
+				}
+				if err := lock.FairCancel(ctx, "waiter"); err != nil {
+					return err
+				}
+				if err := lock.FairUnLock(ctx, "holder"); err != nil {
+					return err
+				}
+				return lock.FairLock(ctx, "next")
+			},
+			want: nil,
+		},
+		{
+			name: "优先级公平锁-高优先级先获取",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key")
+				if err := lock.PriorityFairLock(ctx, "holder", 0); err != nil {
+					return err
+				}
+				if err := lock.PriorityFairLock(ctx, "low", 0); !errors.Is(err, redislock.ErrNotQueueHead) {
+					return err
+				}
+				rdb.Clock().Advance(time.Second)
+				// 高优先级请求直接排到队首，等待持锁者释放
+				if err := lock.PriorityFairLock(ctx, "high", 1); !errors.Is(err, redislock.ErrLockHeld) {
+					return err
+				}
+				if err := lock.FairUnLock(ctx, "holder"); err != nil {
+					return err
+				}
+				return lock.PriorityFairLock(ctx, "high", 1)
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run(New())
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected error %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestReadWriteLock(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		run  func(rdb *Redis) error
+		want error
+	}{
+		{
+			name: "读锁-读读并发",
+			run: func(rdb *Redis) error {
+				if err := redislock.New(rdb, "key", redislock.WithToken("a")).RLock(ctx); err != nil {
+					return err
+				}
+				return redislock.New(rdb, "key", redislock.WithToken("b")).RLock(ctx)
+			},
+			want: nil,
+		},
+		{
+			name: "写锁-存在其他读者",
+			run: func(rdb *Redis) error {
+				if err := redislock.New(rdb, "key", redislock.WithToken("a")).RLock(ctx); err != nil {
+					return err
+				}
+				return redislock.New(rdb, "key", redislock.WithToken("b")).WLock(ctx)
+			},
+			want: redislock.ErrWrongMode,
+		},
+		{
+			name: "写锁-读锁升级为写锁",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key", redislock.WithToken("a"))
+				if err := lock.RLock(ctx); err != nil {
+					return err
+				}
+				if err := lock.WLock(ctx); err != nil {
+					return err
+				}
+				if err := lock.WUnLock(ctx); err != nil {
+					return err
+				}
+				// 写锁释放后切回读模式，他人可加读锁
+				return redislock.New(rdb, "key", redislock.WithToken("b")).RLock(ctx)
+			},
+			want: nil,
+		},
+		{
+			name: "读锁-他人持有写锁",
+			run: func(rdb *Redis) error {
+				if err := redislock.New(rdb, "key", redislock.WithToken("a")).WLock(ctx); err != nil {
+					return err
+				}
+				return redislock.New(rdb, "key", redislock.WithToken("b")).RLock(ctx)
+			},
+			want: redislock.ErrWrongMode,
+		},
+		{
+			name: "写锁-过期后他人可加锁",
+			run: func(rdb *Redis) error {
+				if err := redislock.New(rdb, "key", redislock.WithToken("a")).WLock(ctx); err != nil {
+					return err
+				}
+				rdb.Clock().Advance(6 * time.Second)
+				return redislock.New(rdb, "key", redislock.WithToken("b")).WLock(ctx)
+			},
+			want: nil,
+		},
+		{
+			name: "读锁-全部释放后删除锁",
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key", redislock.WithToken("a"))
+				if err := lock.RLock(ctx); err != nil {
+					return err
+				}
+				if err := lock.RUnLock(ctx); err != nil {
+					return err
+				}
+				if rdb.Exists("key") {
+					return errors.New("read lock key not deleted")
+				}
+				return lock.RRenew(ctx)
+			},
+			want: redislock.ErrLockExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run(New())
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected error %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMinReplicas(t *testing.T) {
+	ctx := context.Background()
+	replicated := redislock.WithMinReplicas(1, 10*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		replicas int64
+		run      func(rdb *Redis) error
+		want     error
+	}{
+		{
+			name:     "副本确认足够",
+			replicas: 1,
+			run: func(rdb *Redis) error {
+				lock := redislock.New(rdb, "key", replicated)
+				if err := lock.Lock(ctx); err != nil {
+					return err
+				}
+				return lock.Renew(ctx)
+			},
+			want: nil,
+		},
+		{
+			name: "副本确认不足-加锁回滚",
+			run: func(rdb *Redis) error {
+				err := redislock.New(rdb, "key", redislock.WithToken("a"), replicated).Lock(ctx)
+				if !errors.Is(err, redislock.ErrLockFailed) {
+					return err
+				}
+				if rdb.Exists("{key}") {
+					return errors.New("lock not rolled back")
+				}
+				return err
+			},
+			want: redislock.ErrNotEnoughReplicas,
+		},
+		{
+			name: "副本确认不足-重入只回滚本次计数",
+			run: func(rdb *Redis) error {
+				if err := redislock.New(rdb, "key", redislock.WithToken("a")).Lock(ctx); err != nil {
+					return err
+				}
+				if err := redislock.New(rdb, "key", redislock.WithToken("a"), replicated).Lock(ctx); err == nil {
+					return errors.New("expected reentry to fail")
+				}
+				// 仍持有第一次加锁，解锁一次即释放
+				if err := redislock.New(rdb, "key", redislock.WithToken("a")).UnLock(ctx); err != nil {
+					return err
+				}
+				return redislock.New(rdb, "key", redislock.WithToken("b")).Lock(ctx)
+			},
+			want: nil,
+		},
+		{
+			name: "副本确认不足-公平锁回滚",
+			run: func(rdb *Redis) error {
+				err := redislock.New(rdb, "key", replicated).FairLock(ctx, "r1")
+				if err == nil {
+					return errors.New("expected fair lock to fail")
+				}
+				if e := redislock.New(rdb, "key").FairLock(ctx, "r2"); e != nil {
+					return e
+				}
+				return err
+			},
+			want: redislock.ErrNotEnoughReplicas,
+		},
+		{
+			name: "副本确认不足-写锁回滚",
+			run: func(rdb *Redis) error {
+				err := redislock.New(rdb, "key", redislock.WithToken("a"), replicated).WLock(ctx)
+				if e := redislock.New(rdb, "key", redislock.WithToken("b")).RLock(ctx); e != nil {
+					return e
+				}
+				return err
+			},
+			want: redislock.ErrNotEnoughReplicas,
+		},
+		{
+			name: "副本确认不足-续期失败",
+			run: func(rdb *Redis) error {
+				if err := redislock.New(rdb, "key", redislock.WithToken("a")).RLock(ctx); err != nil {
+					return err
+				}
+				err := redislock.New(rdb, "key", redislock.WithToken("a"), replicated).RRenew(ctx)
+				if !errors.Is(err, redislock.ErrLockRenewFailed) {
+					return err
+				}
+				return err
+			},
+			want: redislock.ErrNotEnoughReplicas,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rdb := New()
+			rdb.SetReplicas(tt.replicas)
+			err := tt.run(rdb)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected error %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLockError(t *testing.T) {
+	ctx := context.Background()
+	rdb := New()
+
+	if err := redislock.New(rdb, "order:42", redislock.WithToken("worker-7")).Lock(ctx); err != nil {
+		t.Fatalf("Lock() returned unexpected error: %v", err)
+	}
+	rdb.Clock().Advance(1800 * time.Millisecond)
+
+	var lockErr *redislock.LockError
+	err := redislock.New(rdb, "order:42").Lock(ctx)
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("expected *LockError, got %v", err)
+	}
+	if len(lockErr.Holders) != 1 || lockErr.Holders[0] != "worker-7" {
+		t.Errorf("unexpected holders: %v", lockErr.Holders)
+	}
+	if lockErr.TTL != 3200*time.Millisecond {
+		t.Errorf("unexpected ttl: %v", lockErr.TTL)
+	}
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	rdb := New()
+
+	tests := []struct {
+		name    string
+		args    []interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "SET NX", args: []interface{}{"SET", "k", "v", "NX", "PX", 1000}, want: "OK"},
+		{name: "SET NX 已存在", args: []interface{}{"SET", "k", "v2", "NX"}, want: nil},
+		{name: "GET", args: []interface{}{"GET", "k"}, want: "v"},
+		{name: "PTTL", args: []interface{}{"PTTL", "k"}, want: int64(1000)},
+		{name: "HINCRBY 类型错误", args: []interface{}{"HINCRBY", "k", "f", 1}, wantErr: true},
+		{name: "ZADD", args: []interface{}{"ZADD", "z", "NX", 2, "b", 1, "a"}, want: int64(2)},
+		{name: "ZRANK", args: []interface{}{"ZRANK", "z", "b"}, want: int64(1)},
+		{name: "ZSCORE", args: []interface{}{"ZSCORE", "z", "b"}, want: "2"},
+		{name: "ZRANGEBYSCORE", args: []interface{}{"ZRANGEBYSCORE", "z", "-inf", "(2"}, want: []interface{}{"a"}},
+		{name: "未知命令", args: []interface{}{"NOPE"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rdb.Do(ctx, tt.args...).Result()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// slowRedis 之后的 n 次调用在 ctx 结束前不返回，模拟 Redis 响应缓慢
+type slowRedis struct {
+	redislock.RedisInter
+
+	mu   sync.Mutex
+	slow int
+}
+
+func (s *slowRedis) Stall(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slow = n
+}
+
+func (s *slowRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) redislock.RedisCmd {
+	s.mu.Lock()
+	stall := s.slow > 0
+	if stall {
+		s.slow--
+	}
+	s.mu.Unlock()
+
+	if stall {
+		<-ctx.Done()
+		return NewCmd(nil, ctx.Err())
+	}
+	return s.RedisInter.Eval(ctx, script, keys, args...)
+}
+
+// renewed 分两步推进假时钟，合计超过锁超时时间，每步之间留出续期时间；
+// 返回 key 是否仍存在，即锁在此期间是否一直被续期
+func renewed(rdb *Redis, key string, timeout time.Duration) bool {
+	for i := 0; i < 2; i++ {
+		rdb.Clock().Advance(timeout * 2 / 3)
+		time.Sleep(timeout / 2)
+	}
+	return rdb.Exists(key)
+}
+
+// 自动续期的生命周期可以与加锁的 ctx 解耦，每次续期使用独立的超时时间
+func TestRenewLifetime(t *testing.T) {
+	timeout := 300 * time.Millisecond
+
+	constructors := []struct {
+		name string
+		new  func(rdb redislock.RedisInter, options ...redislock.Option) redislock.RedisLockInter
+	}{
+		{name: "New", new: func(rdb redislock.RedisInter, options ...redislock.Option) redislock.RedisLockInter {
+			return redislock.New(rdb, "key", options...)
+		}},
+		{name: "Client", new: func(rdb redislock.RedisInter, options ...redislock.Option) redislock.RedisLockInter {
+			return redislock.NewClient(rdb).NewLock("key", options...)
+		}},
+	}
+
+	for _, c := range constructors {
+		t.Run(c.name, func(t *testing.T) {
+			t.Run("默认随加锁的 ctx 停止", func(t *testing.T) {
+				rdb := New()
+				ctx, cancel := context.WithCancel(context.Background())
+				lock := c.new(rdb, redislock.WithTimeout(timeout), redislock.WithAutoRenew())
+				if err := lock.Lock(ctx); err != nil {
+					t.Fatalf("lock: %v", err)
+				}
+
+				if !renewed(rdb, "{key}", timeout) {
+					t.Fatal("expected lock to be renewed")
+				}
+				cancel()
+				time.Sleep(timeout / 2)
+				if renewed(rdb, "{key}", timeout) {
+					t.Error("expected renewal to stop with the acquire context")
+				}
+			})
+
+			t.Run("只在 UnLock 时停止", func(t *testing.T) {
+				rdb := New()
+				ctx, cancel := context.WithCancel(context.Background())
+				lock := c.new(rdb, redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+					redislock.WithRenewUntilUnLock())
+				if err := lock.Lock(ctx); err != nil {
+					t.Fatalf("lock: %v", err)
+				}
+
+				cancel()
+				if !renewed(rdb, "{key}", timeout) {
+					t.Fatal("expected renewal to outlive the acquire context")
+				}
+				if err := lock.UnLock(context.Background()); err != nil {
+					t.Fatalf("unlock: %v", err)
+				}
+			})
+
+			t.Run("随独立的生命周期 ctx 停止", func(t *testing.T) {
+				rdb := New()
+				ctx, cancel := context.WithCancel(context.Background())
+				lifetime, stop := context.WithCancel(context.Background())
+				defer stop()
+				lock := c.new(rdb, redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+					redislock.WithRenewContext(lifetime))
+				if err := lock.Lock(ctx); err != nil {
+					t.Fatalf("lock: %v", err)
+				}
+
+				cancel()
+				if !renewed(rdb, "{key}", timeout) {
+					t.Fatal("expected renewal to outlive the acquire context")
+				}
+
+				stop()
+				time.Sleep(timeout / 2)
+				if renewed(rdb, "{key}", timeout) {
+					t.Error("expected renewal to stop with the lifetime context")
+				}
+			})
+
+			t.Run("单次续期超时后重试", func(t *testing.T) {
+				rdb := New()
+				slow := &slowRedis{RedisInter: rdb}
+				lock := c.new(slow, redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+					redislock.WithRenewTimeout(30*time.Millisecond), redislock.WithLogger(&recordLogger{}))
+				if err := lock.Lock(context.Background()); err != nil {
+					t.Fatalf("lock: %v", err)
+				}
+				defer lock.UnLock(context.Background())
+
+				// 超时的续期在 200ms 后重试
+				slow.Stall(1)
+				time.Sleep(timeout + timeout/3)
+				if !renewed(rdb, "{key}", timeout) {
+					t.Error("expected renewal to recover after a timed out call")
+				}
+			})
+		})
+	}
+}
+
+// recordFailures 记录续期失败回调
+type recordFailures struct {
+	mu       sync.Mutex
+	failures []redislock.RenewFailure
+}
+
+func (r *recordFailures) add(failure redislock.RenewFailure) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, failure)
+}
+
+func (r *recordFailures) list() []redislock.RenewFailure {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]redislock.RenewFailure(nil), r.failures...)
+}
+
+// 续期间隔、续期 TTL、可容忍的连续失败次数与失败回调
+func TestRenewPolicy(t *testing.T) {
+	ctx := context.Background()
+	timeout := 300 * time.Millisecond
+
+	t.Run("续期 TTL", func(t *testing.T) {
+		rdb := New()
+		lock := redislock.New(rdb, "key", redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+			redislock.WithRenewTTL(time.Minute))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		defer lock.UnLock(ctx)
+
+		if pttl, _ := rdb.Do(ctx, "PTTL", "{key}").Int64(); pttl > timeout.Milliseconds() {
+			t.Fatalf("expected initial lease %v, got %dms", timeout, pttl)
+		}
+		time.Sleep(timeout / 2)
+		if pttl, _ := rdb.Do(ctx, "PTTL", "{key}").Int64(); pttl <= timeout.Milliseconds() {
+			t.Errorf("expected renewal TTL of 1m, got %dms", pttl)
+		}
+	})
+
+	t.Run("续期间隔", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		lock := redislock.New(faulty, "key", redislock.WithTimeout(time.Minute), redislock.WithAutoRenew(),
+			redislock.WithRenewInterval(50*time.Millisecond))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+		defer lock.UnLock(ctx)
+
+		if n := waitCalls(faulty, 275*time.Millisecond); n < 4 || n > 6 {
+			t.Errorf("expected about 5 renewals, got %d", n)
+		}
+	})
+
+	tests := []struct {
+		name        string
+		err         error
+		failures    int
+		maxFailures int
+		wantLost    bool
+		wantCalls   int
+	}{
+		{name: "默认不重试非故障切换错误", err: errors.New("ERR unknown"), failures: 1, wantLost: true, wantCalls: 1},
+		{name: "容忍连续失败", err: errors.New("ERR unknown"), failures: 2, maxFailures: 2, wantLost: false, wantCalls: 2},
+		{name: "超过连续失败次数", err: errors.New("ERR unknown"), failures: -1, maxFailures: 2, wantLost: true, wantCalls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faulty := NewFaultInjector(New())
+			failures := &recordFailures{}
+			lock := redislock.New(faulty, "key", redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+				redislock.WithMaxRenewFailures(tt.maxFailures), redislock.WithRenewFailure(failures.add),
+				redislock.WithRenewInterval(20*time.Millisecond), redislock.WithLogger(&recordLogger{}))
+			if err := lock.Lock(ctx); err != nil {
+				t.Fatalf("lock: %v", err)
+			}
+			defer lock.UnLock(ctx)
+
+			faulty.Fail(tt.err, tt.failures)
+			time.Sleep(timeout / 2)
+
+			got := failures.list()
+			if len(got) != tt.wantCalls {
+				t.Fatalf("expected %d failure callbacks, got %+v", tt.wantCalls, got)
+			}
+			for i, f := range got {
+				if f.Key != "key" || f.Failures != i+1 || !errors.Is(f.Err, tt.err) {
+					t.Errorf("unexpected failure %d: %+v", i, f)
+				}
+				if want := tt.wantLost && i == len(got)-1; f.Lost != want {
+					t.Errorf("failure %d: Lost = %v, want %v", i, f.Lost, want)
+				}
+			}
+		})
+	}
+
+	t.Run("锁过期后的续期判定锁丢失", func(t *testing.T) {
+		rdb := New()
+		faulty := NewFaultInjector(rdb)
+		failures := &recordFailures{}
+		lock := redislock.NewClient(faulty).NewLock("key", redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+			redislock.WithMaxRenewFailures(5), redislock.WithRenewFailure(failures.add),
+			redislock.WithLogger(&recordLogger{}))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+
+		rdb.Clock().Advance(timeout)
+		time.Sleep(timeout)
+
+		got := failures.list()
+		if len(got) != 1 || !got[0].Lost || !errors.Is(got[0].Err, redislock.ErrLockExpired) {
+			t.Fatalf("expected a single lock lost failure, got %+v", got)
+		}
+		if n := waitCalls(faulty, timeout/2); n != 0 {
+			t.Errorf("expected renewal to stop, got %d calls", n)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package redislocktest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// SetReplicas 设置 WAIT 返回的确认副本数，默认为 0（没有副本），用于测试 WithMinReplicas
+func (r *Redis) SetReplicas(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replicas = n
+}
+
+// DoWait 实现 redislock.RedisWaitInter：执行 EVAL 命令，随后返回 SetReplicas 设置的确认副本数
+func (r *Redis) DoWait(ctx context.Context, command []interface{}, numReplicas int, timeout time.Duration) (redislock.RedisCmd, int64, error) {
+	if len(command) < 3 || toString(command[0]) != "EVAL" {
+		return &Cmd{err: fmt.Errorf("redislocktest: unsupported command %v", command)}, 0, nil
+	}
+
+	numKeys, err := strconv.Atoi(toString(command[2]))
+	if err != nil || numKeys < 0 || 3+numKeys > len(command) {
+		return &Cmd{err: fmt.Errorf("ERR Number of keys can't be greater than number of args")}, 0, nil
+	}
+	keys := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = toString(command[3+i])
+	}
+
+	cmd := r.Eval(ctx, toString(command[1]), keys, command[3+numKeys:]...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return cmd, r.replicas, nil
+}
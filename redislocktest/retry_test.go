@@ -0,0 +1,222 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// 解锁、续期遇到临时错误时重试，脚本的确定结果与非临时错误不重试
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	timeout := errors.New("read tcp 127.0.0.1:52814->127.0.0.1:6379: i/o timeout")
+
+	tests := []struct {
+		name      string
+		err       error
+		failures  int
+		options   []redislock.Option
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "连接被重置后重试成功", err: ErrConnReset, failures: 2, wantCalls: 3},
+		{name: "网络超时后重试成功", err: timeout, failures: 1, wantCalls: 2},
+		{name: "LOADING 后重试成功", err: ErrLoading, failures: 1, wantCalls: 2},
+		{name: "超过重试次数", err: ErrConnReset, failures: 3, wantCalls: 3, wantErr: redislock.ErrException},
+		{name: "自定义重试次数", err: ErrConnReset, failures: 3, wantCalls: 4,
+			options: []redislock.Option{redislock.WithRetry(3, time.Millisecond)}},
+		{name: "关闭重试", err: ErrConnReset, failures: 1, wantCalls: 1, wantErr: redislock.ErrException,
+			options: []redislock.Option{redislock.WithRetry(0, 0)}},
+		{name: "READONLY 不重试", err: ErrReadOnly, failures: 1, wantCalls: 1, wantErr: redislock.ErrFailover},
+		{name: "其他错误不重试", err: errors.New("ERR unknown"), failures: 1, wantCalls: 1, wantErr: redislock.ErrException},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faulty := NewFaultInjector(New())
+			lock := redislock.New(faulty, "key", tt.options...)
+			if err := lock.Lock(ctx); err != nil {
+				t.Fatalf("lock: %v", err)
+			}
+
+			faulty.Fail(tt.err, tt.failures)
+			before := faulty.Calls()
+			err := lock.UnLock(ctx)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got := faulty.Calls() - before; got != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, got)
+			}
+		})
+	}
+
+	t.Run("脚本结果不重试", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		if err := redislock.New(faulty, "key").Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+
+		before := faulty.Calls()
+		err := redislock.New(faulty, "key", redislock.WithToken("other")).UnLock(ctx)
+		if !errors.Is(err, redislock.ErrNotOwner) {
+			t.Fatalf("expected ErrNotOwner, got %v", err)
+		}
+		if got := faulty.Calls() - before; got != 1 {
+			t.Errorf("expected 1 call, got %d", got)
+		}
+	})
+
+	t.Run("续期重试", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		tests := []struct {
+			name  string
+			lock  func(l redislock.RedisLockInter) error
+			renew func(l redislock.RedisLockInter) error
+		}{
+			{name: "普通锁", lock: func(l redislock.RedisLockInter) error { return l.Lock(ctx) },
+				renew: func(l redislock.RedisLockInter) error { return l.Renew(ctx) }},
+			{name: "读锁", lock: func(l redislock.RedisLockInter) error { return l.RLock(ctx) },
+				renew: func(l redislock.RedisLockInter) error { return l.RRenew(ctx) }},
+			{name: "写锁", lock: func(l redislock.RedisLockInter) error { return l.WLock(ctx) },
+				renew: func(l redislock.RedisLockInter) error { return l.WRenew(ctx) }},
+			{name: "公平锁", lock: func(l redislock.RedisLockInter) error { return l.FairLock(ctx, "req") },
+				renew: func(l redislock.RedisLockInter) error { return l.FairRenew(ctx, "req") }},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				lock := redislock.New(faulty, "renew-"+tt.name)
+				if err := tt.lock(lock); err != nil {
+					t.Fatalf("lock: %v", err)
+				}
+				faulty.Fail(ErrConnReset, 1)
+				if err := tt.renew(lock); err != nil {
+					t.Errorf("renew: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("ctx 取消后停止重试", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		lock := redislock.New(faulty, "key", redislock.WithRetry(5, time.Hour))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+
+		faulty.Fail(ErrConnReset, -1)
+		ctxCancel, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if err := lock.UnLock(ctxCancel); !errors.Is(err, ErrConnReset) {
+			t.Fatalf("expected ErrConnReset, got %v", err)
+		}
+		if got := faulty.Calls(); got != 2 {
+			t.Errorf("expected 2 calls, got %d", got)
+		}
+	})
+
+	t.Run("请求已执行但回复丢失", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			lock   func(l redislock.RedisLockInter) error
+			unlock func(l redislock.RedisLockInter) error
+		}{
+			{name: "可重入锁",
+				lock: func(l redislock.RedisLockInter) error { return l.Lock(ctx) }, unlock: func(l redislock.RedisLockInter) error { return l.UnLock(ctx) }},
+			{name: "读锁",
+				lock: func(l redislock.RedisLockInter) error { return l.RLock(ctx) }, unlock: func(l redislock.RedisLockInter) error { return l.RUnLock(ctx) }},
+			{name: "写锁",
+				lock: func(l redislock.RedisLockInter) error { return l.WLock(ctx) }, unlock: func(l redislock.RedisLockInter) error { return l.WUnLock(ctx) }},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rdb := New()
+				faulty := NewFaultInjector(rdb)
+				lock := redislock.New(faulty, "key", redislock.WithToken("a"), redislock.WithRetry(2, time.Millisecond))
+				for i := 0; i < 2; i++ {
+					if err := tt.lock(lock); err != nil {
+						t.Fatalf("lock: %v", err)
+					}
+				}
+
+				// 第一次解锁已执行但回复丢失，重试不会再次减少计数
+				faulty.Drop(ErrConnReset, 1)
+				if err := tt.unlock(lock); err != nil {
+					t.Fatalf("unlock: %v", err)
+				}
+				if faulty.Failures() != 1 {
+					t.Fatalf("expected the reply to be dropped once, got %d", faulty.Failures())
+				}
+				if len(lockKeys(rdb)) == 0 {
+					t.Fatal("expected lock to be held once more")
+				}
+
+				// 最后一次解锁已释放锁，重试仍报告成功，其他客户端此时可以加锁
+				faulty.Drop(ErrConnReset, 1)
+				if err := tt.unlock(lock); err != nil {
+					t.Fatalf("unlock: %v", err)
+				}
+				if keys := lockKeys(rdb); len(keys) != 0 {
+					t.Errorf("expected lock to be released, got %v", keys)
+				}
+			})
+		}
+
+		t.Run("被其他客户端加锁后重试", func(t *testing.T) {
+			rdb := New()
+			faulty := NewFaultInjector(rdb)
+			a := redislock.New(faulty, "key", redislock.WithToken("a"), redislock.WithRetry(2, 20*time.Millisecond))
+			if err := a.Lock(ctx); err != nil {
+				t.Fatalf("lock: %v", err)
+			}
+
+			faulty.Drop(ErrConnReset, 1)
+			unlocked := make(chan error, 1)
+			go func() { unlocked <- a.UnLock(ctx) }()
+			for rdb.Exists("{key}") {
+				time.Sleep(time.Millisecond)
+			}
+			if err := redislock.New(rdb, "key", redislock.WithToken("b")).Lock(ctx); err != nil {
+				t.Fatalf("lock: %v", err)
+			}
+			if err := <-unlocked; err != nil {
+				t.Errorf("expected retried unlock to succeed, got %v", err)
+			}
+		})
+	})
+
+	t.Run("重试时锁已过期", func(t *testing.T) {
+		rdb := New()
+		faulty := NewFaultInjector(rdb)
+		lock := redislock.New(faulty, "key", redislock.WithRetry(2, time.Millisecond))
+		if err := lock.Lock(ctx); err != nil {
+			t.Fatalf("lock: %v", err)
+		}
+
+		// 第一次请求未到达 Redis，锁随后过期：重试如实报告锁已过期
+		rdb.Clock().Advance(time.Minute)
+		faulty.Fail(ErrConnReset, 1)
+		if err := lock.UnLock(ctx); !errors.Is(err, redislock.ErrLockExpired) {
+			t.Errorf("expected ErrLockExpired, got %v", err)
+		}
+		if faulty.Failures() != 1 {
+			t.Errorf("expected the first attempt to fail, got %d failures", faulty.Failures())
+		}
+	})
+}
+
+// lockKeys 返回锁本身的 key，不包括解锁记录
+func lockKeys(rdb *Redis) []string {
+	var keys []string
+	for _, key := range rdb.Keys() {
+		if !strings.Contains(key, ":release:") {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,166 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// crossSlot 模拟 Redis Cluster：多 key 脚本返回 CROSSSLOT
+type crossSlot struct {
+	*FaultInjector
+}
+
+func (c crossSlot) Eval(ctx context.Context, script string, keys []string, args ...interface{}) redislock.RedisCmd {
+	if len(keys) > 1 {
+		return NewCmd(nil, errors.New("CROSSSLOT Keys in request don't hash to the same slot"))
+	}
+	return c.FaultInjector.Eval(ctx, script, keys, args...)
+}
+
+// countScripts 按脚本名称统计执行次数
+type countScripts struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *countScripts) ObserveScript(event redislock.ScriptEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[event.Script]++
+}
+
+func (c *countScripts) count(script string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[script]
+}
+
+// Client 创建的自动续期锁由共享调度器批量续期，每把锁单独处理续期结果
+func TestRenewScheduler(t *testing.T) {
+	ctx := context.Background()
+	timeout := 300 * time.Millisecond
+
+	// lockAll 通过 client 加锁 n 把各类型的锁
+	lockAll := func(t *testing.T, client *redislock.Client, n int) []func() error {
+		var unlocks []func() error
+		for i := 0; i < n; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			var err error
+			switch i % 4 {
+			case 0:
+				lock := client.NewLock(key)
+				err, unlocks = lock.Lock(ctx), append(unlocks, func() error { return lock.UnLock(ctx) })
+			case 1:
+				lock := client.NewFairLock(key)
+				err, unlocks = lock.Lock(ctx), append(unlocks, func() error { return lock.UnLock(ctx) })
+			case 2:
+				lock := client.NewRWLock(key)
+				err, unlocks = lock.RLock(ctx), append(unlocks, func() error { return lock.RUnLock(ctx) })
+			case 3:
+				lock := client.NewRWLock(key)
+				err, unlocks = lock.WLock(ctx), append(unlocks, func() error { return lock.WUnLock(ctx) })
+			}
+			if err != nil {
+				t.Fatalf("lock %s: %v", key, err)
+			}
+		}
+		return unlocks
+	}
+
+	t.Run("批量续期", func(t *testing.T) {
+		rdb := New()
+		metrics := &countScripts{}
+		client := redislock.NewClient(rdb, redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+			redislock.WithMetrics(metrics))
+		unlocks := lockAll(t, client, 40)
+
+		if !renewed(rdb, "{key-0}", timeout) || !renewed(rdb, "key-39", timeout) {
+			t.Fatal("expected locks to be renewed")
+		}
+		if got := metrics.count("batchRenew"); got == 0 || got > 20 {
+			t.Errorf("expected a few batch renewals, got %d", got)
+		}
+		for _, name := range []string{"reentrantRenew", "fairRenew", "readRenew", "writeRenew"} {
+			if got := metrics.count(name); got != 0 {
+				t.Errorf("expected no single %s, got %d", name, got)
+			}
+		}
+
+		for _, unlock := range unlocks {
+			if err := unlock(); err != nil {
+				t.Errorf("unlock: %v", err)
+			}
+		}
+		before := metrics.count("batchRenew")
+		time.Sleep(timeout)
+		if got := metrics.count("batchRenew"); got != before {
+			t.Errorf("renewal continued after unlock: %d batch renewals", got-before)
+		}
+	})
+
+	t.Run("单把锁丢失不影响其他锁", func(t *testing.T) {
+		rdb := New()
+		logger := &recordLogger{}
+		client := redislock.NewClient(rdb, redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+			redislock.WithLogger(logger))
+		lockAll(t, client, 4)
+
+		for _, key := range rdb.Keys() {
+			if strings.HasPrefix(key, "{key-0}") {
+				rdb.Do(ctx, "DEL", key)
+			}
+		}
+		time.Sleep(timeout / 2)
+		if rdb.Exists("{key-0}") {
+			t.Error("expected lost lock not to be recreated")
+		}
+		if !renewed(rdb, "{key-1}", timeout) || !rdb.Exists("key-2") || !rdb.Exists("key-3") {
+			t.Error("expected the other locks to be renewed")
+		}
+		if got := strings.Count(logger.String(), "lock lost"); got != 1 {
+			t.Errorf("expected 1 lost lock to be logged, got %d: %s", got, logger.String())
+		}
+	})
+
+	t.Run("故障切换错误在宽限期内重试", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		client := redislock.NewClient(faulty, redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+			redislock.WithLogger(&recordLogger{}))
+		lockAll(t, client, 4)
+
+		faulty.Fail(ErrReadOnly, 1)
+		time.Sleep(3 * timeout)
+		if faulty.Failures() != 1 {
+			t.Fatalf("expected 1 failed batch renewal, got %d", faulty.Failures())
+		}
+		if n := waitCalls(faulty, timeout); n == 0 {
+			t.Error("renewal stopped after failover error")
+		}
+	})
+
+	t.Run("集群回退为逐个续期", func(t *testing.T) {
+		faulty := NewFaultInjector(New())
+		metrics := &countScripts{}
+		client := redislock.NewClient(crossSlot{faulty}, redislock.WithTimeout(timeout), redislock.WithAutoRenew(),
+			redislock.WithMetrics(metrics))
+		lockAll(t, client, 4)
+
+		time.Sleep(3 * timeout)
+		if got := metrics.count("batchRenew"); got != 1 {
+			t.Errorf("expected batching to stop after CROSSSLOT, got %d batch renewals", got)
+		}
+		if metrics.count("reentrantRenew") == 0 || metrics.count("writeRenew") == 0 {
+			t.Error("expected locks to be renewed one by one")
+		}
+	})
+}
@@ -0,0 +1,671 @@
+package redislocktest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// builtinScripts 内置脚本在内存中的等价实现，key 与 redislock.Scripts() 的脚本名称一致
+// 每个实现都与 lua 目录下同名脚本的逻辑一一对应，修改脚本时需同步修改
+var builtinScripts = map[string]scriptFunc{
+	"reentrantLock":      reentrantLock,
+	"reentrantUnLock":    dedupeRelease(reentrantReleaseKey, reentrantUnLock),
+	"reentrantRenew":     reentrantRenew,
+	"fairLock":           fairLock,
+	"fairUnlock":         fairUnlock,
+	"fairRenew":          fairRenew,
+	"fairCancel":         fairCancel,
+	"priorityLock":       priorityLock,
+	"readLock":           readLock,
+	"readUnLock":         dedupeRelease(rwReleaseKey, readUnLock),
+	"readRenew":          readRenew,
+	"writeLock":          writeLock,
+	"writeUnLock":        dedupeRelease(rwReleaseKey, writeUnLock),
+	"writeRenew":         writeRenew,
+	"multiLock":          multiLock,
+	"multiUnLock":        multiUnLock,
+	"multiRenew":         multiRenew,
+	"batchRenew":         batchRenew,
+	"singleflightGet":    singleflightGet,
+	"singleflightSet":    singleflightSet,
+	"singleflightNotify": singleflightNotify,
+	"electionElect":      electionElect,
+	"electionLeader":     electionLeader,
+	"electionResign":     electionResign,
+	"latchTrySet":        latchTrySet,
+	"latchCountDown":     latchCountDown,
+	"latchGet":           latchGet,
+}
+
+// 脚本返回码，与 lua 脚本保持一致
+const (
+	codeOK           int64 = 1
+	codeQueueFull    int64 = -1
+	codeLockHeld     int64 = -2
+	codeNotQueueHead int64 = -3
+	codeWrongMode    int64 = -4
+	codeNotOwner     int64 = -5
+	codeLockExpired  int64 = -6
+)
+
+// --- 普通锁（可重入） ---
+
+func reentrantLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	lockValue := arg(args, 0)
+	lockTTL := argInt(args, 1)
+	reentrantKey := lockKey + ":count:" + lockValue
+
+	// 可重入锁计数器
+	if count := strInt(k.get(reentrantKey)); count > 0 {
+		k.incrBy(reentrantKey, 1)
+		k.pexpire(lockKey, lockTTL)
+		k.pexpire(reentrantKey, lockTTL)
+		return codeOK, nil
+	}
+
+	// 创建锁
+	if k.setNXPX(lockKey, lockValue, lockTTL) {
+		k.set(reentrantKey, "1")
+		k.pexpire(reentrantKey, lockTTL)
+		return codeOK, nil
+	}
+
+	return []interface{}{codeLockHeld, k.pttl(lockKey), optional(k.get(lockKey))}, nil
+}
+
+func reentrantUnLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	lockValue := arg(args, 0)
+	reentrantKey := lockKey + ":count:" + lockValue
+
+	count := strInt(k.get(reentrantKey))
+	if count > 1 {
+		k.incrBy(reentrantKey, -1)
+		return codeOK, nil
+	} else if count == 1 {
+		k.del(reentrantKey)
+		if holder, ok := k.get(lockKey); ok && holder == lockValue {
+			k.del(lockKey)
+			return codeOK, nil
+		}
+	}
+
+	holder, ok := k.get(lockKey)
+	if ok && holder == lockValue {
+		k.del(lockKey)
+		return codeOK, nil
+	}
+	if !ok {
+		return codeLockExpired, nil
+	}
+	return codeNotOwner, nil
+}
+
+func reentrantRenew(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	lockValue := arg(args, 0)
+	lockTTL := argInt(args, 1)
+	reentrantKey := lockKey + ":count:" + lockValue
+
+	count := strInt(k.get(reentrantKey))
+	holder, ok := k.get(lockKey)
+	if count > 0 || (ok && holder == lockValue) {
+		k.pexpire(lockKey, lockTTL)
+		k.pexpire(reentrantKey, lockTTL)
+		return codeOK, nil
+	}
+	if !ok {
+		return codeLockExpired, nil
+	}
+	return codeNotOwner, nil
+}
+
+// --- 公平锁 ---
+
+// fairFail 公平锁加锁失败：返回原因、锁剩余有效期与当前持有者
+func fairFail(k *keyspace, lockKey string, code int64) []interface{} {
+	if holder, ok := k.get(lockKey); ok {
+		return []interface{}{code, k.pttl(lockKey), holder}
+	}
+	return []interface{}{code, int64(-2)}
+}
+
+func fairLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	queueKey := lockKey + ":queue"
+	requestId := arg(args, 0)
+	lockTTL := argInt(args, 1)
+	requestTimeout := argInt(args, 2)
+	maxQueueLength := argInt(args, 3)
+
+	// 与脚本一致，使用秒级时间换算为毫秒
+	now := k.clock.Now().Unix() * 1000
+
+	// 清理超时的请求
+	k.zremRangeByScore(queueKey, 0, float64(now-requestTimeout))
+
+	// 准入控制
+	if maxQueueLength > 0 {
+		if _, queued := k.zscore(queueKey, requestId); !queued && k.zcard(queueKey) >= maxQueueLength {
+			return fairFail(k, lockKey, codeQueueFull), nil
+		}
+	}
+
+	// 排队
+	k.zaddNX(queueKey, float64(now), requestId)
+	k.pexpire(queueKey, requestTimeout)
+
+	if k.zrank(queueKey, requestId) != 0 {
+		return fairFail(k, lockKey, codeNotQueueHead), nil
+	}
+
+	if k.setNXPX(lockKey, requestId, lockTTL) {
+		return codeOK, nil
+	}
+	return fairFail(k, lockKey, codeLockHeld), nil
+}
+
+func fairUnlock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	queueKey := lockKey + ":queue"
+	requestId := arg(args, 0)
+
+	if holder, ok := k.get(lockKey); ok && holder == requestId {
+		k.del(lockKey)
+	}
+	k.zrem(queueKey, requestId)
+	k.zrem(queueKey+":ts", requestId)
+
+	return codeOK, nil
+}
+
+func fairRenew(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	requestId := arg(args, 0)
+	lockTTL := argInt(args, 1)
+
+	holder, ok := k.get(lockKey)
+	if ok && holder == requestId {
+		k.pexpire(lockKey, lockTTL)
+		return codeOK, nil
+	}
+	if !ok {
+		return codeLockExpired, nil
+	}
+	return codeNotOwner, nil
+}
+
+func fairCancel(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	queueKey := lockKey + ":queue"
+	requestId := arg(args, 0)
+
+	if holder, ok := k.get(lockKey); ok && holder == requestId {
+		return int64(0), nil
+	}
+	k.zrem(queueKey, requestId)
+	k.zrem(queueKey+":ts", requestId)
+
+	return codeOK, nil
+}
+
+func priorityLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	queueKey := lockKey + ":queue"
+	queueTsKey := queueKey + ":ts"
+	requestId := arg(args, 0)
+	lockTTL := argInt(args, 1)
+	requestTimeout := argInt(args, 2)
+	maxQueueLength := argInt(args, 3)
+	priority := argInt(args, 4)
+	aging := argInt(args, 5)
+
+	now := k.nowMillis()
+
+	// 清理超时的请求（按入队时间）
+	expired := k.zrangeByScore(queueTsKey, 0, float64(now-requestTimeout))
+	k.zrem(queueKey, expired...)
+	k.zremRangeByScore(queueTsKey, 0, float64(now-requestTimeout))
+
+	// 准入控制
+	if maxQueueLength > 0 {
+		if _, queued := k.zscore(queueKey, requestId); !queued && k.zcard(queueKey) >= maxQueueLength {
+			return fairFail(k, lockKey, codeQueueFull), nil
+		}
+	}
+
+	// 计算排序分值
+	step := 1e12
+	if aging > 0 {
+		step = float64(aging)
+	}
+	score := float64(now) - float64(priority)*step
+
+	// 排队
+	k.zaddNX(queueKey, score, requestId)
+	k.zaddNX(queueTsKey, float64(now), requestId)
+	k.pexpire(queueKey, requestTimeout)
+	k.pexpire(queueTsKey, requestTimeout)
+
+	if k.zrank(queueKey, requestId) != 0 {
+		return fairFail(k, lockKey, codeNotQueueHead), nil
+	}
+
+	if k.setNXPX(lockKey, requestId, lockTTL) {
+		return codeOK, nil
+	}
+	return fairFail(k, lockKey, codeLockHeld), nil
+}
+
+// --- 读锁 ---
+
+func readLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	key := keys[0]
+	lockValue := arg(args, 0)
+	lockTTL := argInt(args, 1)
+
+	mode, ok := k.hget(key, "mode")
+	if !ok {
+		k.hset(key, "mode", "read", "rcount", "1", "r:"+lockValue, "1")
+		k.pexpire(key, lockTTL)
+		return codeOK, nil
+	}
+
+	// 读读并发，或自己持有写锁时读写可重入
+	writer, _ := k.hget(key, "writer")
+	if mode == "read" || (mode == "write" && writer == lockValue) {
+		k.hincrBy(key, "r:"+lockValue, 1)
+		k.hincrBy(key, "rcount", 1)
+		k.pexpire(key, lockTTL)
+		return codeOK, nil
+	}
+
+	return []interface{}{codeWrongMode, k.pttl(key), optional(k.hget(key, "writer"))}, nil
+}
+
+func readUnLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	key := keys[0]
+	lockValue := arg(args, 0)
+
+	selfCnt := strInt(k.hget(key, "r:"+lockValue))
+	if selfCnt <= 0 {
+		if !k.exists(key) {
+			return codeLockExpired, nil
+		}
+		return codeNotOwner, nil
+	}
+
+	selfCnt = k.hincrBy(key, "r:"+lockValue, -1)
+	k.hincrBy(key, "rcount", -1)
+	if selfCnt == 0 {
+		k.hdel(key, "r:"+lockValue)
+	}
+
+	if total := strInt(k.hget(key, "rcount")); total <= 0 {
+		if mode, _ := k.hget(key, "mode"); mode == "read" {
+			k.del(key)
+		} else {
+			k.hdel(key, "rcount")
+		}
+	}
+
+	return codeOK, nil
+}
+
+func readRenew(k *keyspace, keys []string, args []string) (interface{}, error) {
+	key := keys[0]
+	lockValue := arg(args, 0)
+	lockTTL := argInt(args, 1)
+
+	if selfCnt := strInt(k.hget(key, "r:"+lockValue)); selfCnt <= 0 {
+		if !k.exists(key) {
+			return codeLockExpired, nil
+		}
+		return codeNotOwner, nil
+	}
+
+	k.pexpire(key, lockTTL)
+	return codeOK, nil
+}
+
+// --- 写锁 ---
+
+func writeLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	key := keys[0]
+	lockValue := arg(args, 0)
+	lockTTL := argInt(args, 1)
+
+	mode, ok := k.hget(key, "mode")
+	if !ok {
+		k.hset(key, "mode", "write", "writer", lockValue, "wcount", "1")
+		k.pexpire(key, lockTTL)
+		return codeOK, nil
+	}
+
+	if mode == "write" {
+		writer, hasWriter := k.hget(key, "writer")
+		if hasWriter && writer == lockValue {
+			k.hincrBy(key, "wcount", 1)
+			k.pexpire(key, lockTTL)
+			return codeOK, nil
+		}
+		return []interface{}{codeLockHeld, k.pttl(key), optional(writer, hasWriter)}, nil
+	}
+
+	if mode == "read" {
+		// 仅自己持有读锁，可以升级为写锁
+		total := strInt(k.hget(key, "rcount"))
+		selfCnt := strInt(k.hget(key, "r:"+lockValue))
+		if total == selfCnt {
+			k.hset(key, "mode", "write", "writer", lockValue, "wcount", "1")
+			k.pexpire(key, lockTTL)
+			return codeOK, nil
+		}
+	}
+
+	result := []interface{}{codeWrongMode, k.pttl(key)}
+	for _, field := range k.hkeys(key) {
+		if strings.HasPrefix(field, "r:") {
+			result = append(result, strings.TrimPrefix(field, "r:"))
+		}
+	}
+	return result, nil
+}
+
+func writeUnLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	key := keys[0]
+	lockValue := arg(args, 0)
+
+	if code := checkWriter(k, key, lockValue); code != codeOK {
+		return code, nil
+	}
+
+	if wcount := k.hincrBy(key, "wcount", -1); wcount > 0 {
+		return codeOK, nil
+	}
+
+	k.hdel(key, "writer", "wcount")
+	if rcount := strInt(k.hget(key, "rcount")); rcount > 0 {
+		k.hset(key, "mode", "read")
+	} else {
+		k.del(key)
+	}
+
+	return codeOK, nil
+}
+
+func writeRenew(k *keyspace, keys []string, args []string) (interface{}, error) {
+	key := keys[0]
+	lockValue := arg(args, 0)
+	lockTTL := argInt(args, 1)
+
+	if code := checkWriter(k, key, lockValue); code != codeOK {
+		return code, nil
+	}
+
+	k.pexpire(key, lockTTL)
+	return codeOK, nil
+}
+
+// checkWriter 校验锁模式与写锁持有者
+func checkWriter(k *keyspace, key, lockValue string) int64 {
+	mode, ok := k.hget(key, "mode")
+	if !ok {
+		return codeLockExpired
+	}
+	if mode != "write" {
+		return codeWrongMode
+	}
+	if writer, _ := k.hget(key, "writer"); writer != lockValue {
+		return codeNotOwner
+	}
+	return codeOK
+}
+
+// --- 联锁 ---
+
+func multiLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	if k.setNXPX("{"+keys[0]+"}", arg(args, 0), argInt(args, 1)) {
+		return codeOK, nil
+	}
+	return codeLockHeld, nil
+}
+
+func multiUnLock(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	holder, ok := k.get(lockKey)
+	if ok && holder == arg(args, 0) {
+		k.del(lockKey)
+		return codeOK, nil
+	}
+	if !ok {
+		return codeLockExpired, nil
+	}
+	return codeNotOwner, nil
+}
+
+func multiRenew(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	holder, ok := k.get(lockKey)
+	if ok && holder == arg(args, 0) {
+		k.pexpire(lockKey, argInt(args, 1))
+		return codeOK, nil
+	}
+	if !ok {
+		return codeLockExpired, nil
+	}
+	return codeNotOwner, nil
+}
+
+// --- 解锁去重 ---
+
+// dedupeRelease 解锁脚本按 ARGV[2] 的请求 ID 去重：请求已执行过时直接返回成功，
+// 解锁成功时记录请求 ID，ARGV[3] 为记录的有效期
+func dedupeRelease(releaseKey func(key, owner string) string, unlock scriptFunc) scriptFunc {
+	return func(k *keyspace, keys []string, args []string) (interface{}, error) {
+		releaseId := arg(args, 1)
+		if releaseId == "" {
+			return unlock(k, keys, args)
+		}
+
+		key := releaseKey(keys[0], arg(args, 0))
+		if id, ok := k.get(key); ok && id == releaseId {
+			return codeOK, nil
+		}
+		res, err := unlock(k, keys, args)
+		if res == codeOK {
+			k.set(key, releaseId)
+			k.pexpire(key, argInt(args, 2))
+		}
+		return res, err
+	}
+}
+
+func reentrantReleaseKey(key, owner string) string {
+	return "{" + key + "}:release:" + owner
+}
+
+// rwReleaseKey 读写锁的解锁记录 key：锁 key 含 hash tag 时直接追加后缀，否则以锁 key 作为 hash tag
+func rwReleaseKey(key, owner string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key + ":release:" + owner
+		}
+	}
+	return "{" + key + "}:release:" + owner
+}
+
+// --- 参数转换 ---
+
+func arg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// argInt 与 Lua tonumber 一致，无法解析时返回 0
+func argInt(args []string, i int) int64 {
+	n, _ := strconv.ParseInt(arg(args, i), 10, 64)
+	return n
+}
+
+func strInt(v string, ok bool) int64 {
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseInt(v, 10, 64)
+	return n
+}
+
+// optional 将不存在的值转换为 nil，与 Lua 中 false 转换为 Redis 空回复一致
+func optional(v string, ok bool) interface{} {
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+// --- 批量续期 ---
+
+// batchRenewers 批量续期脚本中锁类型对应的单锁续期实现
+var batchRenewers = map[string]scriptFunc{
+	"reentrant": reentrantRenew,
+	"fair":      fairRenew,
+	"read":      readRenew,
+	"write":     writeRenew,
+}
+
+func batchRenew(k *keyspace, keys []string, args []string) (interface{}, error) {
+	result := make([]interface{}, len(keys))
+	for i, key := range keys {
+		kind := arg(args, 3*i)
+		renew, ok := batchRenewers[kind]
+		if !ok {
+			return nil, fmt.Errorf("unknown lock kind: %s", kind)
+		}
+		code, err := renew(k, []string{key}, []string{arg(args, 3*i+1), arg(args, 3*i+2)})
+		if err != nil {
+			return nil, err
+		}
+		result[i] = code
+	}
+	return result, nil
+}
+
+// --- singleflight ---
+
+func singleflightGet(k *keyspace, keys []string, args []string) (interface{}, error) {
+	resultKey := "{" + keys[0] + "}:result"
+	if result, ok := k.get(resultKey); ok {
+		return []interface{}{codeOK, result}, nil
+	}
+	return []interface{}{int64(0)}, nil
+}
+
+func singleflightNotify(k *keyspace, keys []string, args []string) (interface{}, error) {
+	return k.publish("{"+keys[0]+"}:result", "0"), nil
+}
+
+func singleflightSet(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	resultKey := lockKey + ":result"
+	lockValue := arg(args, 0)
+
+	holder, ok := k.get(lockKey)
+	if !ok {
+		return codeLockExpired, nil
+	}
+	if holder != lockValue {
+		return codeNotOwner, nil
+	}
+
+	k.set(resultKey, arg(args, 1))
+	k.pexpire(resultKey, argInt(args, 2))
+	k.publish(resultKey, "1")
+	return codeOK, nil
+}
+
+// --- 选主 ---
+
+func electionElect(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	leaderKey := lockKey + ":leader"
+	candidate := arg(args, 0)
+
+	holder, ok := k.get(lockKey)
+	if !ok {
+		return codeLockExpired, nil
+	}
+	if holder != candidate {
+		return codeNotOwner, nil
+	}
+
+	term := k.hincrBy(leaderKey, "term", 1)
+	k.hset(leaderKey, "id", candidate, "meta", arg(args, 1))
+	k.publish(leaderKey, strconv.FormatInt(term, 10))
+	return term, nil
+}
+
+func electionLeader(k *keyspace, keys []string, args []string) (interface{}, error) {
+	lockKey := "{" + keys[0] + "}"
+	leaderKey := lockKey + ":leader"
+
+	holder, ok := k.get(lockKey)
+	if !ok {
+		return []interface{}{int64(0)}, nil
+	}
+
+	pttl := k.pttl(lockKey)
+	if id, ok := k.hget(leaderKey, "id"); !ok || id != holder {
+		return []interface{}{codeOK, pttl, holder, "", int64(0)}, nil
+	}
+
+	meta, _ := k.hget(leaderKey, "meta")
+	return []interface{}{codeOK, pttl, holder, meta, strInt(k.hget(leaderKey, "term"))}, nil
+}
+
+func electionResign(k *keyspace, keys []string, args []string) (interface{}, error) {
+	leaderKey := "{" + keys[0] + "}:leader"
+
+	if id, ok := k.hget(leaderKey, "id"); !ok || id != arg(args, 0) {
+		return codeNotOwner, nil
+	}
+
+	k.hdel(leaderKey, "id", "meta")
+	k.publish(leaderKey, "0")
+	return codeOK, nil
+}
+
+// --- CountDownLatch ---
+
+func latchTrySet(k *keyspace, keys []string, args []string) (interface{}, error) {
+	latchKey := "{" + keys[0] + "}:latch"
+	if strInt(k.get(latchKey)) > 0 {
+		return int64(0), nil
+	}
+	k.set(latchKey, arg(args, 0))
+	k.pexpire(latchKey, argInt(args, 1))
+	return codeOK, nil
+}
+
+func latchCountDown(k *keyspace, keys []string, args []string) (interface{}, error) {
+	latchKey := "{" + keys[0] + "}:latch"
+	if strInt(k.get(latchKey)) <= 0 {
+		return int64(0), nil
+	}
+
+	count := k.incrBy(latchKey, -1)
+	k.pexpire(latchKey, argInt(args, 0))
+	if count == 0 {
+		k.publish(latchKey, "0")
+	}
+	return count, nil
+}
+
+func latchGet(k *keyspace, keys []string, args []string) (interface{}, error) {
+	latchKey := "{" + keys[0] + "}:latch"
+	return []interface{}{strInt(k.get(latchKey)), k.pttl(latchKey)}, nil
+}
@@ -0,0 +1,297 @@
+package redislocktest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+)
+
+// evalOnly 只实现 RedisInter，用于测试客户端不支持订阅时退化为轮询
+type evalOnly struct {
+	redislock.RedisInter
+}
+
+// subscribeCounter 统计订阅次数，用于测试不需要等待时不订阅
+type subscribeCounter struct {
+	*Redis
+	subscribes atomic.Int32
+}
+
+func (s *subscribeCounter) Subscribe(ctx context.Context, channel string) (<-chan string, func(), error) {
+	s.subscribes.Add(1)
+	return s.Redis.Subscribe(ctx, channel)
+}
+
+// waitSubscribers 等待 channel 的订阅数达到 n
+func waitSubscribers(t *testing.T, rdb *Redis, channel string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for rdb.Subscribers(channel) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d subscribers on %s, got %d", n, channel, rdb.Subscribers(channel))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// 分布式 singleflight：同一 key 只执行一次 fn，其他调用共享结果
+func TestGroup(t *testing.T) {
+	ctx := context.Background()
+	const waiters = 5
+
+	t.Run("并发调用只执行一次", func(t *testing.T) {
+		rdb := New()
+		group := redislock.NewGroup(rdb, time.Minute)
+
+		var calls atomic.Int32
+		release := make(chan struct{})
+		fn := func(ctx context.Context) ([]byte, error) {
+			calls.Add(1)
+			<-release
+			return []byte("value"), nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]string, waiters)
+		errs := make([]error, waiters)
+		for i := 0; i < waiters; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				val, err := group.Do(ctx, "key", fn)
+				results[i], errs[i] = string(val), err
+			}(i)
+		}
+
+		// 获胜者不订阅，等待者都已订阅通知后获胜者才返回
+		waitSubscribers(t, rdb, "{key}:result", waiters-1)
+		close(release)
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Errorf("expected fn to run once, got %d", calls.Load())
+		}
+		for i := range results {
+			if errs[i] != nil || results[i] != "value" {
+				t.Errorf("call %d: expected shared value, got %q, %v", i, results[i], errs[i])
+			}
+		}
+		if rdb.Subscribers("{key}:result") != 0 {
+			t.Error("expected subscriptions to be released")
+		}
+		if rdb.Exists("{key}") {
+			t.Error("expected lock to be released")
+		}
+	})
+
+	t.Run("有效期内复用结果", func(t *testing.T) {
+		rdb := &subscribeCounter{Redis: New()}
+		group := redislock.NewGroup(rdb, time.Minute)
+
+		var calls int
+		fn := func(ctx context.Context) ([]byte, error) {
+			calls++
+			return []byte("value"), nil
+		}
+
+		for i := 0; i < 2; i++ {
+			if val, err := group.Do(ctx, "key", fn); err != nil || string(val) != "value" {
+				t.Fatalf("do: %q, %v", val, err)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("expected cached result within ttl, fn ran %d times", calls)
+		}
+		// 获胜者与命中结果都无需订阅
+		if n := rdb.subscribes.Load(); n != 0 {
+			t.Errorf("expected no subscription without waiting, got %d", n)
+		}
+
+		rdb.Clock().Advance(time.Minute)
+		if _, err := group.Do(ctx, "key", fn); err != nil {
+			t.Fatalf("do: %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("expected fn to run again after ttl, fn ran %d times", calls)
+		}
+	})
+
+	t.Run("传递获胜者的错误", func(t *testing.T) {
+		rdb := New()
+		group := redislock.NewGroup(rdb, time.Minute)
+		errBiz := errors.New("rebuild failed")
+
+		release := make(chan struct{})
+		winnerErr := make(chan error, 1)
+		go func() {
+			_, err := group.Do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+				<-release
+				return nil, errBiz
+			})
+			winnerErr <- err
+		}()
+
+		// 获胜者持有锁后再发起等待者
+		for !rdb.Exists("{key}") {
+			time.Sleep(time.Millisecond)
+		}
+		loserErr := make(chan error, 1)
+		go func() {
+			_, err := group.Do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+				t.Error("loser should not run fn")
+				return nil, nil
+			})
+			loserErr <- err
+		}()
+		waitSubscribers(t, rdb, "{key}:result", 1)
+		close(release)
+
+		if err := <-winnerErr; !errors.Is(err, errBiz) {
+			t.Errorf("expected winner to get its own error, got %v", err)
+		}
+		err := <-loserErr
+		if !errors.Is(err, redislock.ErrSharedCallFailed) || err.Error() != "shared call failed: rebuild failed" {
+			t.Errorf("expected shared error, got %v", err)
+		}
+
+		// 错误不像结果一样被缓存
+		rdb.Clock().Advance(time.Second)
+		if val, err := group.Do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+			return []byte("value"), nil
+		}); err != nil || string(val) != "value" {
+			t.Errorf("expected fn to run again after error expired, got %q, %v", val, err)
+		}
+	})
+
+	t.Run("不支持订阅时轮询", func(t *testing.T) {
+		rdb := New()
+		group := redislock.NewGroup(evalOnly{rdb}, time.Minute)
+
+		release := make(chan struct{})
+		winner := make(chan error, 1)
+		go func() {
+			_, err := group.Do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+				<-release
+				return []byte("value"), nil
+			})
+			winner <- err
+		}()
+		for !rdb.Exists("{key}") {
+			time.Sleep(time.Millisecond)
+		}
+
+		loser := make(chan []byte, 1)
+		go func() {
+			val, err := group.Do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+				t.Error("loser should not run fn")
+				return nil, nil
+			})
+			if err != nil {
+				t.Errorf("loser: %v", err)
+			}
+			loser <- val
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		if err := <-winner; err != nil {
+			t.Fatalf("winner: %v", err)
+		}
+		select {
+		case val := <-loser:
+			if string(val) != "value" {
+				t.Errorf("expected shared value, got %q", val)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected loser to poll for the result")
+		}
+	})
+
+	t.Run("获胜者放弃后由等待者执行", func(t *testing.T) {
+		rdb := New()
+		group := redislock.NewGroup(rdb, time.Minute)
+
+		ctxWinner, cancel := context.WithCancel(ctx)
+		winner := make(chan error, 1)
+		go func() {
+			_, err := group.Do(ctxWinner, "key", func(ctx context.Context) ([]byte, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			})
+			winner <- err
+		}()
+		for !rdb.Exists("{key}") {
+			time.Sleep(time.Millisecond)
+		}
+
+		loser := make(chan error, 1)
+		var ran atomic.Bool
+		go func() {
+			val, err := group.Do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+				ran.Store(true)
+				return []byte("value"), nil
+			})
+			if err == nil && string(val) != "value" {
+				t.Errorf("expected own value, got %q", val)
+			}
+			loser <- err
+		}()
+		waitSubscribers(t, rdb, "{key}:result", 1)
+
+		cancel()
+		if err := <-winner; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected winner to be canceled, got %v", err)
+		}
+		// 未写入结果，释放锁后通知等待者立即重新加锁，无需等到锁的剩余有效期结束
+		select {
+		case err := <-loser:
+			if err != nil || !ran.Load() {
+				t.Errorf("expected loser to run fn, ran=%v err=%v", ran.Load(), err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected loser to take over")
+		}
+	})
+
+	t.Run("无效的结果有效期", func(t *testing.T) {
+		rdb := New()
+		for _, group := range []*redislock.Group{
+			redislock.NewGroup(rdb, 0),
+			redislock.NewClient(rdb).NewGroup(time.Microsecond),
+		} {
+			_, err := group.Do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+				t.Error("fn should not run")
+				return nil, nil
+			})
+			if !errors.Is(err, redislock.ErrInvalidTTL) {
+				t.Errorf("expected ErrInvalidTTL, got %v", err)
+			}
+		}
+		if len(rdb.Keys()) != 0 {
+			t.Errorf("expected no redis calls, got keys %v", rdb.Keys())
+		}
+	})
+
+	t.Run("客户端默认配置", func(t *testing.T) {
+		rdb := New()
+		client := redislock.NewClient(rdb, redislock.WithKeyPrefix("cache:"))
+		group := client.NewGroup(time.Minute)
+
+		if _, err := group.Do(ctx, "key", func(ctx context.Context) ([]byte, error) {
+			if !rdb.Exists("{cache:key}") {
+				t.Error("expected prefixed lock to be held")
+			}
+			return []byte("value"), nil
+		}); err != nil {
+			t.Fatalf("do: %v", err)
+		}
+		if !rdb.Exists("{cache:key}:result") {
+			t.Error("expected prefixed result key")
+		}
+	})
+}
@@ -0,0 +1,231 @@
+package go_redislock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithRenewContext binds auto-renew to ctx instead of the context passed to Lock.
+// WithRenewContext 设置自动续期的生命周期：续期在 ctx 结束或 UnLock 时停止，与加锁时传入的 ctx 无关。
+// 适用于加锁的 ctx 属于单次请求（如带超时的 HTTP 请求），而业务在后台协程中继续执行的场景
+func WithRenewContext(ctx context.Context) Option {
+	return func(lock *RedisLock) {
+		lock.renewCtx = ctx
+		lock.renewUntilUnLock = false
+	}
+}
+
+// WithRenewUntilUnLock keeps auto-renew running until UnLock, regardless of any context.
+// WithRenewUntilUnLock 设置自动续期只在 UnLock 时停止，加锁时传入的 ctx 结束后仍继续续期。
+// 忘记 UnLock 时锁会一直被续期，请确保每次加锁都会解锁
+func WithRenewUntilUnLock() Option {
+	return func(lock *RedisLock) {
+		lock.renewCtx = nil
+		lock.renewUntilUnLock = true
+	}
+}
+
+// WithRenewTimeout sets the timeout of each renew call made by auto-renew, the renewal interval by default
+// WithRenewTimeout 设置自动续期单次续期的超时时间，默认为续期间隔（锁超时时间的 1/3）。
+// 单次续期超时与故障切换错误一样在宽限期内重试
+func WithRenewTimeout(timeout time.Duration) Option {
+	return func(lock *RedisLock) {
+		lock.renewTimeout = timeout
+	}
+}
+
+// WithRenewInterval sets how often auto-renew extends the lock, a third of the smaller of the lock timeout and the renewal TTL by default
+// WithRenewInterval 设置自动续期的间隔，默认为锁超时时间与续期 TTL 中较小者的 1/3
+func WithRenewInterval(interval time.Duration) Option {
+	return func(lock *RedisLock) {
+		lock.renewInterval = interval
+	}
+}
+
+// WithRenewTTL sets the TTL applied on each renewal, which may differ from the initial lease set by WithTimeout
+// WithRenewTTL 设置每次续期（包括手动续期）设置的 TTL，可与加锁时的超时时间（WithTimeout）不同，默认与其相同
+func WithRenewTTL(ttl time.Duration) Option {
+	return func(lock *RedisLock) {
+		lock.renewTTL = ttl
+	}
+}
+
+// WithMaxRenewFailures sets how many consecutive auto-renew failures are tolerated before the lock is considered lost.
+// WithMaxRenewFailures 设置自动续期可容忍的连续失败次数，超过后判定锁丢失并停止续期。
+// 未设置时只重试故障切换错误与单次续期超时；设置后任意错误都会重试，但锁已过期、不是持有者等确定的结果始终判定锁丢失
+func WithMaxRenewFailures(n int) Option {
+	return func(lock *RedisLock) {
+		lock.maxRenewFailures = n
+	}
+}
+
+// RenewFailure 自动续期失败的信息
+type RenewFailure struct {
+	// Key 锁的 key
+	Key string
+	// Err 续期返回的错误
+	Err error
+	// Failures 连续失败次数
+	Failures int
+	// Lost 是否已判定锁丢失并停止续期
+	Lost bool
+}
+
+// WithRenewFailure sets a callback fired on every auto-renew failure
+// WithRenewFailure 设置自动续期失败回调，每次续期失败都会回调，Lost 为 true 时锁已丢失，应停止依赖该锁的业务。
+// 回调在续期协程中同步执行，不应阻塞
+func WithRenewFailure(fn func(failure RenewFailure)) Option {
+	return func(lock *RedisLock) {
+		lock.onRenewFailure = fn
+	}
+}
+
+// renewParent 返回自动续期的父 ctx：默认为加锁时的 ctx，可通过 WithRenewContext、WithRenewUntilUnLock 修改。
+// WithRenewUntilUnLock 时保留加锁 ctx 中的值（如链路追踪信息），但不随其取消
+func (l *RedisLock) renewParent(ctx context.Context) context.Context {
+	switch {
+	case l.renewCtx != nil:
+		return l.renewCtx
+	case l.renewUntilUnLock:
+		return context.WithoutCancel(ctx)
+	default:
+		return ctx
+	}
+}
+
+// renewLoop 自动续期：每个续期间隔续期一次，每次续期使用独立的超时时间，续期结果由 renewResult 处理
+func (l *RedisLock) renewLoop(ctx context.Context, name string, renew func(ctx context.Context) error) {
+	state := l.newRenewState()
+	timer := time.NewTimer(l.renewPeriod())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		ctxCall, cancel := context.WithTimeout(ctx, l.renewCallTimeout())
+		err := renew(ctxCall)
+		cancel()
+		if err != nil && ctx.Err() != nil {
+			return
+		}
+
+		wait, ok := l.renewResult(name, state, err)
+		if !ok {
+			return
+		}
+		timer.Reset(wait)
+	}
+}
+
+// renewState 单把锁的自动续期状态，renewLoop 与续期调度器共用
+type renewState struct {
+	// lastRenew 加锁或最后一次续期成功的时间
+	lastRenew time.Time
+	// lease 当前租期：加锁后为锁超时时间，续期成功后为续期 TTL，未设置 WithFailoverGrace 时作为宽限期
+	lease time.Duration
+	// failures 连续失败次数
+	failures int
+}
+
+func (l *RedisLock) newRenewState() *renewState {
+	return &renewState{
+		lastRenew: time.Now(),
+		lease:     l.lockTimeout,
+	}
+}
+
+// renewResult 处理一次续期结果，返回距下一次续期的时间，锁丢失时返回 false：
+//   - 续期成功：按续期间隔安排下一次续期
+//   - 锁已过期（续期晚于 key 过期）、不是持有者、锁模式不匹配：判定锁丢失，不再重试
+//   - 其他错误在宽限期内以 failoverRetryInterval 重试。未设置 WithMaxRenewFailures 时只重试故障切换错误与单次续期超时，
+//     设置后重试任意错误，连续失败次数超过上限时判定锁丢失
+func (l *RedisLock) renewResult(name string, state *renewState, err error) (time.Duration, bool) {
+	if err == nil {
+		state.lastRenew = time.Now()
+		state.lease = l.renewLease()
+		state.failures = 0
+		return l.renewPeriod(), true
+	}
+
+	state.failures++
+	lost := !l.renewRetryable(err, state)
+	if l.onRenewFailure != nil {
+		l.onRenewFailure(RenewFailure{Key: l.key, Err: err, Failures: state.failures, Lost: lost})
+	}
+	if lost {
+		l.logf("Error: %s failed, lock lost, %v", name, err)
+		if onLost := l.onLost.Load(); onLost != nil {
+			(*onLost)(err)
+		}
+		return 0, false
+	}
+
+	l.logf("Warn: %s failed, retrying, %v", name, err)
+	return min(failoverRetryInterval, l.renewPeriod()), true
+}
+
+// renewRetryable 续期失败后是否继续重试
+func (l *RedisLock) renewRetryable(err error, state *renewState) bool {
+	if errors.Is(err, ErrLockExpired) || errors.Is(err, ErrNotOwner) || errors.Is(err, ErrWrongMode) {
+		return false
+	}
+
+	grace := l.failoverGrace
+	if grace <= 0 {
+		grace = state.lease
+	}
+	if time.Since(state.lastRenew) >= grace {
+		return false
+	}
+
+	if l.maxRenewFailures > 0 {
+		return state.failures <= l.maxRenewFailures
+	}
+	return isFailoverErr(err) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// renewPeriod 自动续期的间隔，默认为锁超时时间与续期 TTL 中较小者的 1/3
+func (l *RedisLock) renewPeriod() time.Duration {
+	if l.renewInterval > 0 {
+		return l.renewInterval
+	}
+	return min(l.lockTimeout, l.renewLease()) / 3
+}
+
+// renewLease 续期时设置的 TTL，默认为锁超时时间
+func (l *RedisLock) renewLease() time.Duration {
+	if l.renewTTL > 0 {
+		return l.renewTTL
+	}
+	return l.lockTimeout
+}
+
+// renewCallTimeout 单次续期的超时时间，默认为续期间隔
+func (l *RedisLock) renewCallTimeout() time.Duration {
+	if l.renewTimeout > 0 {
+		return l.renewTimeout
+	}
+	return l.renewPeriod()
+}
+
+// startAutoRenew 启动自动续期，UnLock 时通过 autoRenewCancel 停止，ctx 为加锁时的 ctx。
+// 由 Client 创建的锁交给共享的续期调度器批量续期；需要 WAIT 确认副本的锁无法批量续期，与 New 创建的锁一样单独启动续期协程
+func (l *RedisLock) startAutoRenew(ctx context.Context, kind LockKind, id, name string, renew func(ctx context.Context) error) {
+	ctxRenew, cancel := context.WithCancel(l.renewParent(ctx))
+	if l.scheduler != nil && l.minReplicas <= 0 {
+		stop := l.scheduler.add(ctxRenew, l, kind, id, name, renew)
+		l.autoRenewCancel = func() {
+			stop()
+			cancel()
+		}
+		return
+	}
+
+	l.autoRenewCancel = cancel
+	go l.renewLoop(ctxRenew, name, renew)
+}
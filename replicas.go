@@ -0,0 +1,105 @@
+package go_redislock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RedisWaitInter 支持 WAIT 命令的客户端接口，适配器可选实现，WithMinReplicas 依赖该接口。
+// WAIT 只统计同一连接上此前写命令的复制情况，实现需在同一连接上依次执行 command 与 WAIT
+type RedisWaitInter interface {
+	// DoWait 执行 command（EVAL 或 FCALL 及其参数），随后在同一连接上执行 WAIT numReplicas timeout。
+	// 返回 command 的结果与确认写入的副本数，command 的错误通过 RedisCmd 返回，error 仅表示 WAIT 失败
+	DoWait(ctx context.Context, command []interface{}, numReplicas int, timeout time.Duration) (RedisCmd, int64, error)
+}
+
+// errWaitUnsupported 开启 WithMinReplicas 但客户端未实现 RedisWaitInter
+var errWaitUnsupported = errors.New("redis client does not implement RedisWaitInter")
+
+// WithMinReplicas sets the number of replicas that must acknowledge an acquire or renew (via WAIT).
+// An acquisition acknowledged by fewer replicas within timeout is rolled back and fails with ErrNotEnoughReplicas.
+//
+// WithMinReplicas 设置加锁、续期后需通过 WAIT 确认写入的最少副本数，timeout 为 WAIT 的最长等待时间（为 0 时一直等待）。
+// 确认的副本数不足时加锁视为失败并回滚，返回 ErrNotEnoughReplicas，避免主从切换后锁丢失导致两个持有者并存
+func WithMinReplicas(n int, timeout time.Duration) Option {
+	return func(lock *RedisLock) {
+		lock.minReplicas = n
+		lock.replicaTimeout = timeout
+	}
+}
+
+// errCmd 只包含错误的 RedisCmd
+type errCmd struct {
+	err error
+}
+
+func (c errCmd) Result() (interface{}, error) {
+	return nil, c.err
+}
+
+func (c errCmd) Int64() (int64, error) {
+	return 0, c.err
+}
+
+// evalReplicated 执行加锁、续期脚本，开启 WithMinReplicas 时在同一连接上通过 WAIT 等待副本确认。
+// 返回的 error 表示副本确认不足，仅在脚本执行成功时有意义
+func (l *RedisLock) evalReplicated(ctx context.Context, script string, keys []string, args ...interface{}) (RedisCmd, error) {
+	if l.minReplicas <= 0 {
+		return l.eval(ctx, script, keys, args...), nil
+	}
+
+	rw, ok := l.redis.(RedisWaitInter)
+	if !ok {
+		return errCmd{err: errWaitUnsupported}, nil
+	}
+
+	var (
+		acked   int64
+		waitErr error
+	)
+	do := func(command []interface{}) RedisCmd {
+		var cmd RedisCmd
+		cmd, acked, waitErr = rw.DoWait(ctx, command, l.minReplicas, l.replicaTimeout)
+		return cmd
+	}
+	cmd := l.run(ctx, script, scriptRunner{
+		eval: func() RedisCmd {
+			return do(commandArgs("EVAL", script, keys, args))
+		},
+		fcall: func(function string) RedisCmd {
+			return do(commandArgs("FCALL", function, keys, args))
+		},
+	})
+
+	if waitErr != nil {
+		return cmd, fmt.Errorf("%w: %w", ErrNotEnoughReplicas, waitErr)
+	}
+	if acked < int64(l.minReplicas) {
+		return cmd, fmt.Errorf("%w: %d of %d acknowledged", ErrNotEnoughReplicas, acked, l.minReplicas)
+	}
+	return cmd, nil
+}
+
+// commandArgs 组装 EVAL / FCALL 命令：name target numkeys key [key ...] arg [arg ...]
+func commandArgs(name, target string, keys []string, args []interface{}) []interface{} {
+	command := make([]interface{}, 0, 3+len(keys)+len(args))
+	command = append(command, name, target, len(keys))
+	for _, key := range keys {
+		command = append(command, key)
+	}
+	return append(command, args...)
+}
+
+// rollback 副本确认不足时撤销本次加锁。
+// 直接执行解锁脚本而不调用 UnLock，避免取消重入前已启动的自动续期；
+// 调用方的 ctx 此时可能已取消或超时，因此使用独立的短超时 ctx
+func (l *RedisLock) rollback(ctx context.Context, unlockScript string, id string) {
+	ctxRollback, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	if _, err := l.eval(ctxRollback, unlockScript, []string{l.key}, id).Result(); err != nil {
+		l.logf("Error: rollback lock failed, Err: %v \n", err)
+	}
+}
@@ -0,0 +1,86 @@
+package go_redislock
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WithRetry sets how many times release and renew calls are retried on transient errors, with exponential backoff.
+// WithRetry 设置解锁、续期遇到临时错误（网络超时、连接被重置、LOADING 等）时的重试次数与初始退避时间，退避时间逐次翻倍。
+// 默认重试 2 次、退避 50ms，attempts 为 0 时不重试
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(lock *RedisLock) {
+		lock.retryAttempts = attempts
+		lock.retryBackoff = backoff
+	}
+}
+
+// retry 执行 run，返回临时错误时按退避时间重试。
+// 只用于解锁、续期：续期脚本对持有者是幂等的；解锁脚本按 release 传入的请求 ID 去重，
+// 请求已执行但回复丢失时重试不会重复减少计数。脚本返回的任何结果（包括失败码）都是确定的，不会重试
+func (l *RedisLock) retry(ctx context.Context, run func() RedisCmd) RedisCmd {
+	cmd := run()
+	backoff := l.retryBackoff
+	for i := 0; i < l.retryAttempts; i++ {
+		if _, err := cmd.Result(); !isTransientErr(err) {
+			return cmd
+		}
+
+		select {
+		case <-ctx.Done():
+			return cmd
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		cmd = run()
+	}
+	return cmd
+}
+
+// release 执行解锁脚本，args 为脚本 KEYS 之后的参数，返回临时错误时重试。
+// 重试前的请求已执行时由解锁记录返回成功，因此重试返回的失败码都是确定的
+func (l *RedisLock) release(ctx context.Context, script string, args ...interface{}) error {
+	result, err := l.retry(ctx, func() RedisCmd {
+		return l.eval(ctx, script, []string{l.key}, args...)
+	}).Int64()
+
+	if err != nil {
+		return exceptionErr(err)
+	}
+	if result != codeOK {
+		return codeErr(ErrUnLockFailed, result)
+	}
+	return nil
+}
+
+// releaseRecord 返回本次解锁的请求 ID 与解锁记录的有效期（毫秒），解锁脚本据此对重试去重。
+// 不重试时无需去重，返回空的请求 ID
+func (l *RedisLock) releaseRecord() (string, int64) {
+	if l.retryAttempts <= 0 {
+		return "", 0
+	}
+	// 全部重试的退避时间之和小于 retryBackoff << retryAttempts
+	ttl := l.retryBackoff<<min(l.retryAttempts, 16) + releaseRecordGrace
+	return uuid.New().String(), ttl.Milliseconds()
+}
+
+// isTransientErr 判断错误是否为可重试的临时错误：连接错误、网络超时，以及服务端的 LOADING、TRYAGAIN。
+// 调用方取消或超时、熔断器熔断不重试
+func isTransientErr(err error) bool {
+	if err == nil ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrRedisUnavailable) {
+		return false
+	}
+	if isTransportErr(err) {
+		return true
+	}
+
+	msg := err.Error()
+	return strings.HasPrefix(msg, "LOADING ") || strings.HasPrefix(msg, "TRYAGAIN ")
+}
@@ -0,0 +1,226 @@
+package go_redislock
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed lua/batchRenew.lua
+var batchRenewScript string
+
+// renewScheduler 共享续期调度器，由 Client 创建，为该 Client 创建的全部自动续期锁续期。
+// 到期时间相近的锁合并为一次 batchRenew 脚本调用，每把锁单独处理返回码（见 renewResult），锁丢失时移出调度器
+type renewScheduler struct {
+	// exec 执行批量续期脚本，提供 Redis 客户端、函数库、重试、日志与监控等配置
+	exec *RedisLock
+
+	mu      sync.Mutex
+	entries map[*renewEntry]struct{}
+	running bool
+	// wake 新锁加入时唤醒调度协程，重新计算最早的续期时间
+	wake chan struct{}
+	// unbatched 服务端拒绝跨 slot 的多 key 脚本（Redis Cluster）后改为逐个续期
+	unbatched bool
+}
+
+// renewEntry 调度器中的一把锁
+// next、state 只在调度协程中访问
+type renewEntry struct {
+	lock  *RedisLock
+	kind  LockKind
+	id    string
+	name  string
+	renew func(ctx context.Context) error
+	next  time.Time // 下一次续期时间
+	state *renewState
+}
+
+func newRenewScheduler(exec *RedisLock) *renewScheduler {
+	return &renewScheduler{
+		exec:    exec,
+		entries: make(map[*renewEntry]struct{}),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// add 将锁加入调度器，ctx 结束或调用返回的 stop 后移出调度器。
+// kind 为批量续期脚本中的锁类型，id 为持有者标识，renew 用于无法批量续期时单独续期
+func (s *renewScheduler) add(ctx context.Context, lock *RedisLock, kind LockKind, id, name string,
+	renew func(ctx context.Context) error) (stop func()) {
+	e := &renewEntry{
+		lock:  lock,
+		kind:  kind,
+		id:    id,
+		name:  name,
+		renew: renew,
+		next:  time.Now().Add(lock.renewPeriod()),
+		state: lock.newRenewState(),
+	}
+
+	s.mu.Lock()
+	s.entries[e] = struct{}{}
+	if !s.running {
+		s.running = true
+		go s.loop()
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	remove := func() {
+		s.mu.Lock()
+		delete(s.entries, e)
+		s.mu.Unlock()
+	}
+	stopAfter := context.AfterFunc(ctx, remove)
+	return func() {
+		stopAfter()
+		remove()
+	}
+}
+
+// loop 调度协程：等待最早的续期时间，续期到期的锁，调度器为空时退出
+func (s *renewScheduler) loop() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		if len(s.entries) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		var earliest time.Time
+		for e := range s.entries {
+			if earliest.IsZero() || e.next.Before(earliest) {
+				earliest = e.next
+			}
+		}
+		s.mu.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(time.Until(earliest))
+		select {
+		case <-timer.C:
+			s.renewDue()
+		case <-s.wake:
+		}
+	}
+}
+
+// renewDue 续期到期时间在 renewBatchWindow 内的锁，每批最多 renewBatchSize 把
+func (s *renewScheduler) renewDue() {
+	deadline := time.Now().Add(renewBatchWindow)
+
+	s.mu.Lock()
+	var due []*renewEntry
+	for e := range s.entries {
+		if !e.next.After(deadline) {
+			due = append(due, e)
+		}
+	}
+	unbatched := s.unbatched
+	s.mu.Unlock()
+
+	for len(due) > 0 {
+		n := min(len(due), renewBatchSize)
+		batch := due[:n]
+		due = due[n:]
+
+		if unbatched || len(batch) == 1 {
+			s.renewEach(batch)
+			continue
+		}
+		if !s.renewBatch(batch) {
+			unbatched = true
+			s.renewEach(batch)
+		}
+	}
+}
+
+// renewBatch 通过一次脚本调用续期 batch 中的锁。
+// 服务端拒绝跨 slot 的多 key 脚本时返回 false，由调用方改为逐个续期
+func (s *renewScheduler) renewBatch(batch []*renewEntry) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), s.exec.renewCallTimeout())
+	defer cancel()
+
+	keys := make([]string, 0, len(batch))
+	args := make([]interface{}, 0, 3*len(batch))
+	for _, e := range batch {
+		keys = append(keys, e.lock.key)
+		args = append(args, string(e.kind), e.id, e.lock.renewLease().Milliseconds())
+	}
+
+	res, err := s.exec.retry(ctx, func() RedisCmd {
+		return s.exec.eval(ctx, batchRenewScript, keys, args...)
+	}).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "CROSSSLOT") {
+		s.mu.Lock()
+		s.unbatched = true
+		s.mu.Unlock()
+		return false
+	}
+
+	codes, ok := res.([]interface{})
+	if err == nil && (!ok || len(codes) != len(batch)) {
+		err = fmt.Errorf("unexpected batch renew result: %v", res)
+	}
+	for i, e := range batch {
+		if err != nil {
+			s.done(e, exceptionErr(err))
+			continue
+		}
+		code, convErr := toInt64(codes[i])
+		if convErr != nil {
+			s.done(e, exceptionErr(convErr))
+			continue
+		}
+		s.done(e, codeErr(ErrLockRenewFailed, code))
+	}
+	return true
+}
+
+// renewEach 逐个续期 batch 中的锁
+func (s *renewScheduler) renewEach(batch []*renewEntry) {
+	for _, e := range batch {
+		ctx, cancel := context.WithTimeout(context.Background(), e.lock.renewCallTimeout())
+		err := e.renew(ctx)
+		cancel()
+		s.done(e, err)
+	}
+}
+
+// done 处理单把锁的续期结果，锁丢失时移出调度器。
+// renewResult 可能回调 WithRenewFailure，回调中可能解锁，因此不能持有 s.mu
+func (s *renewScheduler) done(e *renewEntry, err error) {
+	// 续期期间锁已解锁
+	s.mu.Lock()
+	_, ok := s.entries[e]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	wait, ok := e.lock.renewResult(e.name, e.state, err)
+	if ok {
+		e.next = time.Now().Add(wait)
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.entries, e)
+	s.mu.Unlock()
+}
@@ -0,0 +1,38 @@
+package go_redislock
+
+// Scripts returns all embedded Lua scripts keyed by name (the file name under lua/ without extension).
+// It is intended for tooling such as test doubles that need to recognize which script is being executed.
+//
+// Scripts 返回内置的全部 Lua 脚本，key 为脚本名称（lua 目录下去掉扩展名的文件名）。
+// 主要供测试替身等工具识别当前执行的是哪个脚本。
+func Scripts() map[string]string {
+	return map[string]string{
+		"reentrantLock":      reentrantLockScript,
+		"reentrantUnLock":    reentrantUnLockScript,
+		"reentrantRenew":     reentrantRenewScript,
+		"fairLock":           fairLockScript,
+		"fairUnlock":         fairUnLockScript,
+		"fairRenew":          fairRenewScript,
+		"fairCancel":         fairCancelScript,
+		"priorityLock":       priorityLockScript,
+		"readLock":           readLockScript,
+		"readUnLock":         readUnLockScript,
+		"readRenew":          readRenewScript,
+		"writeLock":          writeLockScript,
+		"writeUnLock":        writeUnLockScript,
+		"writeRenew":         writeRenewScript,
+		"multiLock":          multiLockScript,
+		"multiUnLock":        multiUnLockScript,
+		"multiRenew":         multiRenewScript,
+		"batchRenew":         batchRenewScript,
+		"singleflightGet":    singleflightGetScript,
+		"singleflightSet":    singleflightSetScript,
+		"singleflightNotify": singleflightNotifyScript,
+		"electionElect":      electionElectScript,
+		"electionLeader":     electionLeaderScript,
+		"electionResign":     electionResignScript,
+		"latchTrySet":        latchTrySetScript,
+		"latchCountDown":     latchCountDownScript,
+		"latchGet":           latchGetScript,
+	}
+}
@@ -0,0 +1,228 @@
+package go_redislock
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	//go:embed lua/singleflightGet.lua
+	singleflightGetScript string
+	//go:embed lua/singleflightSet.lua
+	singleflightSetScript string
+	//go:embed lua/singleflightNotify.lua
+	singleflightNotifyScript string
+)
+
+// 共享结果的序列化前缀
+const (
+	resultValuePrefix = "v:"
+	resultErrorPrefix = "e:"
+)
+
+// 共享错误的最长有效期：只需让正在等待的进程读到，不应像结果一样被缓存
+const sharedErrTTL = time.Second
+
+// Group 分布式 singleflight：同一 key 同一时间只有一个进程（获胜者）执行 fn，
+// 其他进程等待获胜者的通知后读取其结果，而不是加锁失败返回 ErrLockFailed。
+// 结果序列化后保存在锁的同级 key（{key}:result）中，在 resultTTL 内重复调用直接返回该结果
+//
+//	group := redislock.NewGroup(rdb, time.Minute)
+//	data, err := group.Do(ctx, "cache:user:42", func(ctx context.Context) ([]byte, error) {
+//		return rebuild(ctx)
+//	})
+type Group struct {
+	resultTTL time.Duration
+	newLock   func(key string) *RedisLock
+	// 创建时的配置错误，Do 直接返回
+	err error
+}
+
+// NewGroup creates a distributed singleflight group, results are shared for resultTTL
+// NewGroup 创建分布式 singleflight，获胜者的结果保留 resultTTL。resultTTL 需不小于 1ms，否则 Do 返回 ErrInvalidTTL。
+// options 为锁的配置，锁固定开启自动续期，fn 执行时间不受锁超时时间限制
+func NewGroup(redisClient RedisInter, resultTTL time.Duration, options ...Option) *Group {
+	options = append([]Option{WithAutoRenew()}, options...)
+	return newGroup(resultTTL, func(key string) *RedisLock {
+		return newRedisLock(redisClient, key, options...)
+	})
+}
+
+// NewGroup creates a distributed singleflight group with the client defaults, see NewGroup
+// NewGroup 使用客户端默认配置创建分布式 singleflight，行为与 NewGroup 一致
+func (c *Client) NewGroup(resultTTL time.Duration, options ...Option) *Group {
+	options = append([]Option{WithAutoRenew()}, options...)
+	return newGroup(resultTTL, func(key string) *RedisLock {
+		return c.newLock(key, options)
+	})
+}
+
+func newGroup(resultTTL time.Duration, newLock func(key string) *RedisLock) *Group {
+	group := &Group{resultTTL: resultTTL, newLock: newLock}
+	// 结果的有效期以毫秒写入（SET PX），小于 1ms 时无法写入结果，等待者会依次重新执行 fn
+	if resultTTL < time.Millisecond {
+		group.err = ErrInvalidTTL
+	}
+	return group
+}
+
+// Do executes fn once per key across processes and shares its result.
+// Do 对同一 key 只执行一次 fn 并共享结果：
+//   - 已有未过期的结果时直接返回，不执行 fn
+//   - 获胜者在持有锁期间执行 fn，锁丢失时 fn 的 ctx 被取消（见 Do 辅助函数）
+//   - 其他进程等待获胜者写入结果后返回同一结果；获胜者的 fn 返回错误时，
+//     其他进程返回满足 errors.Is(err, ErrSharedCallFailed) 的错误，错误信息与获胜者一致
+//   - 获胜者未写入结果即释放锁（如调用方取消、锁丢失）时，由等待者中的一个重新执行 fn
+//
+// 客户端实现 RedisPubSubInter 时通过订阅通知及时唤醒等待者，否则轮询等待
+func (g *Group) Do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	lock := g.newLock(key)
+
+	// 只有等待者需要订阅，命中结果与获胜者不订阅
+	var sub *subscription
+	defer func() {
+		sub.close()
+	}()
+
+	for {
+		val, found, err := g.load(ctx, lock)
+		if found || err != nil {
+			return val, err
+		}
+
+		var executed, stored bool
+		err = Do(ctx, lock, func(ctx context.Context) error {
+			executed = true
+			val, stored, err = g.execute(ctx, lock, fn)
+			return err
+		})
+		if executed {
+			// 未写入结果时锁已释放，通知等待者重新加锁
+			if !stored {
+				g.notify(ctx, lock)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return val, nil
+		}
+
+		// 锁被获胜者持有，等待通知或锁到期后重试
+		var lockErr *LockError
+		if !errors.As(err, &lockErr) || !errors.Is(err, ErrLockHeld) {
+			return nil, err
+		}
+		// 首次等待前订阅，订阅后重新读取结果，避免订阅之前发出的通知丢失
+		if sub == nil {
+			sub = subscribe(ctx, lock.redis, resultChannel(lock.key))
+			continue
+		}
+		wait := lockErr.TTL
+		if wait < 0 {
+			wait = lock.lockTimeout
+		}
+		if err = sub.wait(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// execute 持有锁期间执行 fn 并写入共享结果，stored 表示已有共享结果
+func (g *Group) execute(ctx context.Context, lock *RedisLock, fn func(ctx context.Context) ([]byte, error)) (val []byte, stored bool, err error) {
+	// 读取结果与加锁之间，上一个获胜者可能已写入结果并释放锁
+	if val, found, err := g.load(ctx, lock); found || err != nil {
+		return val, found, err
+	}
+
+	val, fnErr := fn(ctx)
+
+	// 调用方取消或锁丢失时不写入结果，由等待者重新执行
+	if ctx.Err() != nil {
+		return val, false, fnErr
+	}
+
+	result, ttl := resultValuePrefix+string(val), g.resultTTL
+	if fnErr != nil {
+		result, ttl = resultErrorPrefix+fnErr.Error(), min(g.resultTTL, sharedErrTTL)
+	}
+	if err := g.store(ctx, lock, result, ttl); err != nil {
+		lock.logf("Error: singleflight store result failed, Err: %v \n", err)
+		return val, false, fnErr
+	}
+
+	return val, true, fnErr
+}
+
+// load 读取共享结果，found 表示已有结果（包括获胜者的错误）
+func (g *Group) load(ctx context.Context, lock *RedisLock) (val []byte, found bool, err error) {
+	res, err := lock.eval(ctx, singleflightGetScript, []string{lock.key}).Result()
+	if err != nil {
+		return nil, false, exceptionErr(err)
+	}
+
+	arr, ok := res.([]interface{})
+	if !ok || len(arr) == 0 {
+		return nil, false, errors.Join(fmt.Errorf("unexpected singleflight result: %v", res), ErrException)
+	}
+	code, err := toInt64(arr[0])
+	if err != nil {
+		return nil, false, exceptionErr(err)
+	}
+	if code != codeOK || len(arr) < 2 {
+		return nil, false, nil
+	}
+
+	var result string
+	switch v := arr[1].(type) {
+	case string:
+		result = v
+	case []byte:
+		result = string(v)
+	default:
+		return nil, false, errors.Join(fmt.Errorf("unexpected singleflight result: %T", v), ErrException)
+	}
+
+	if msg, ok := strings.CutPrefix(result, resultErrorPrefix); ok {
+		return nil, true, fmt.Errorf("%w: %s", ErrSharedCallFailed, msg)
+	}
+	if value, ok := strings.CutPrefix(result, resultValuePrefix); ok {
+		return []byte(value), true, nil
+	}
+	return nil, false, errors.Join(fmt.Errorf("unexpected singleflight result: %q", result), ErrException)
+}
+
+// store 写入共享结果并通知等待者，锁已不属于当前持有者时不写入
+func (g *Group) store(ctx context.Context, lock *RedisLock, result string, ttl time.Duration) error {
+	res, err := lock.eval(ctx, singleflightSetScript,
+		[]string{lock.key},
+		lock.token,
+		result,
+		ttl.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return exceptionErr(err)
+	}
+	return codeErr(ErrLockLost, res)
+}
+
+// notify 通知等待者锁已释放。调用方的 ctx 可能已取消，使用独立的短超时 ctx
+func (g *Group) notify(ctx context.Context, lock *RedisLock) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer cancel()
+
+	if _, err := lock.eval(ctx, singleflightNotifyScript, []string{lock.key}).Result(); err != nil {
+		lock.logf("Error: singleflight notify failed, Err: %v \n", err)
+	}
+}
+
+// resultChannel 共享结果的通知频道，与结果 key 同名
+func resultChannel(key string) string {
+	return "{" + key + "}:result"
+}
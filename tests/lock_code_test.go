@@ -0,0 +1,269 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/stretchr/testify/require"
+)
+
+// 各类锁失败时返回具体原因，同时兼容通用错误
+func Test_LockFailReason(t *testing.T) {
+	adapter := getRedisClient()
+
+	tests := []struct {
+		name     string
+		inputKey string
+		before   func(ctx context.Context, key string)
+		action   func(ctx context.Context, key string) error
+		wantErrs []error
+	}{
+		{
+			name:     "普通锁-锁被占用",
+			inputKey: "code_lock_held",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("other")).Lock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).Lock(ctx)
+			},
+			wantErrs: []error{redislock.ErrLockFailed, redislock.ErrLockHeld},
+		},
+		{
+			name:     "普通锁-解锁时非持有者",
+			inputKey: "code_unlock_not_owner",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("other")).Lock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).UnLock(ctx)
+			},
+			wantErrs: []error{redislock.ErrUnLockFailed, redislock.ErrNotOwner},
+		},
+		{
+			name:     "普通锁-解锁时锁已过期",
+			inputKey: "code_unlock_expired",
+			before: func(ctx context.Context, key string) {
+				lock := redislock.New(adapter, key, redislock.WithToken("self"), redislock.WithTimeout(200*time.Millisecond))
+				require.NoError(t, lock.Lock(ctx))
+				time.Sleep(500 * time.Millisecond)
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key, redislock.WithToken("self")).UnLock(ctx)
+			},
+			wantErrs: []error{redislock.ErrUnLockFailed, redislock.ErrLockExpired},
+		},
+		{
+			name:     "普通锁-续期时锁已过期",
+			inputKey: "code_renew_expired",
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).Renew(ctx)
+			},
+			wantErrs: []error{redislock.ErrLockRenewFailed, redislock.ErrLockExpired},
+		},
+		{
+			name:     "公平锁-不是队首",
+			inputKey: "code_fair_not_head",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key).FairLock(ctx, "holder"))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).FairLock(ctx, "waiter")
+			},
+			wantErrs: []error{redislock.ErrLockFailed, redislock.ErrNotQueueHead},
+		},
+		{
+			name:     "公平锁-队首但锁仍被占用",
+			inputKey: "code_fair_head_held",
+			before: func(ctx context.Context, key string) {
+				// 持锁者在队列中的请求超时被清理，但锁仍未过期
+				lock := redislock.New(adapter, key, redislock.WithRequestTimeout(time.Second))
+				require.NoError(t, lock.FairLock(ctx, "holder"))
+				time.Sleep(2 * time.Second)
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key, redislock.WithRequestTimeout(time.Second)).FairLock(ctx, "waiter")
+			},
+			wantErrs: []error{redislock.ErrLockFailed, redislock.ErrLockHeld},
+		},
+		{
+			name:     "公平锁-续期时非持有者",
+			inputKey: "code_fair_renew_not_owner",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key).FairLock(ctx, "holder"))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).FairRenew(ctx, "waiter")
+			},
+			wantErrs: []error{redislock.ErrLockRenewFailed, redislock.ErrNotOwner},
+		},
+		{
+			name:     "读锁-他人持有写锁",
+			inputKey: "code_rlock_wrong_mode",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("writer")).WLock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).RLock(ctx)
+			},
+			wantErrs: []error{redislock.ErrLockFailed, redislock.ErrWrongMode},
+		},
+		{
+			name:     "写锁-他人持有写锁",
+			inputKey: "code_wlock_held",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("writer")).WLock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).WLock(ctx)
+			},
+			wantErrs: []error{redislock.ErrLockFailed, redislock.ErrLockHeld},
+		},
+		{
+			name:     "写锁-存在其他读者",
+			inputKey: "code_wlock_wrong_mode",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("reader")).RLock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).WLock(ctx)
+			},
+			wantErrs: []error{redislock.ErrLockFailed, redislock.ErrWrongMode},
+		},
+		{
+			name:     "写锁-读模式下解写锁",
+			inputKey: "code_wunlock_wrong_mode",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("reader")).RLock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key, redislock.WithToken("reader")).WUnLock(ctx)
+			},
+			wantErrs: []error{redislock.ErrUnLockFailed, redislock.ErrWrongMode},
+		},
+		{
+			name:     "读锁-解锁时非持有者",
+			inputKey: "code_runlock_not_owner",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("reader")).RLock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).RUnLock(ctx)
+			},
+			wantErrs: []error{redislock.ErrUnLockFailed, redislock.ErrNotOwner},
+		},
+		{
+			name:     "读锁-续期时锁已过期",
+			inputKey: "code_rrenew_expired",
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).RRenew(ctx)
+			},
+			wantErrs: []error{redislock.ErrLockRenewFailed, redislock.ErrLockExpired},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.before != nil {
+				tt.before(ctx, tt.inputKey)
+			}
+
+			err := tt.action(ctx, tt.inputKey)
+			for _, want := range tt.wantErrs {
+				require.ErrorIs(t, err, want)
+			}
+		})
+	}
+}
+
+// 加锁失败时可通过 errors.As 获取 key、锁类型、持有者与剩余有效期
+func Test_LockError(t *testing.T) {
+	adapter := getRedisClient()
+
+	tests := []struct {
+		name        string
+		inputKey    string
+		before      func(ctx context.Context, key string)
+		action      func(ctx context.Context, key string) error
+		wantKind    redislock.LockKind
+		wantHolders []string
+		wantReason  error
+	}{
+		{
+			name:     "普通锁-返回持有者与剩余有效期",
+			inputKey: "lock_error_reentrant",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("worker-7")).Lock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).Lock(ctx)
+			},
+			wantKind:    redislock.KindReentrant,
+			wantHolders: []string{"worker-7"},
+			wantReason:  redislock.ErrLockHeld,
+		},
+		{
+			name:     "公平锁-返回持有者与剩余有效期",
+			inputKey: "lock_error_fair",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key).FairLock(ctx, "holder_req"))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).FairLock(ctx, "waiter_req")
+			},
+			wantKind:    redislock.KindFair,
+			wantHolders: []string{"holder_req"},
+			wantReason:  redislock.ErrNotQueueHead,
+		},
+		{
+			name:     "写锁-返回所有读锁持有者",
+			inputKey: "lock_error_write",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("reader-1")).RLock(ctx))
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("reader-2")).RLock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).WLock(ctx)
+			},
+			wantKind:    redislock.KindWrite,
+			wantHolders: []string{"reader-1", "reader-2"},
+			wantReason:  redislock.ErrWrongMode,
+		},
+		{
+			name:     "读锁-返回写锁持有者",
+			inputKey: "lock_error_read",
+			before: func(ctx context.Context, key string) {
+				require.NoError(t, redislock.New(adapter, key, redislock.WithToken("writer")).WLock(ctx))
+			},
+			action: func(ctx context.Context, key string) error {
+				return redislock.New(adapter, key).RLock(ctx)
+			},
+			wantKind:    redislock.KindRead,
+			wantHolders: []string{"writer"},
+			wantReason:  redislock.ErrWrongMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tt.before(ctx, tt.inputKey)
+
+			err := tt.action(ctx, tt.inputKey)
+			require.ErrorIs(t, err, redislock.ErrLockFailed)
+			require.ErrorIs(t, err, tt.wantReason)
+
+			var lockErr *redislock.LockError
+			require.ErrorAs(t, err, &lockErr)
+			require.Equal(t, tt.inputKey, lockErr.Key)
+			require.Equal(t, tt.wantKind, lockErr.Kind)
+			require.ElementsMatch(t, tt.wantHolders, lockErr.Holders)
+			require.Greater(t, lockErr.TTL, time.Duration(0))
+			require.LessOrEqual(t, lockErr.TTL, 5*time.Second)
+			t.Log(err)
+		})
+	}
+}
@@ -183,3 +183,177 @@ func Test_SpinFairLock(t *testing.T) {
 		})
 	}
 }
+
+func Test_FairCancel(t *testing.T) {
+	tests := []struct {
+		name        string
+		inputKey    string
+		waiter      func(ctx context.Context, lock redislock.RedisLockInter) error
+		wantWaitErr error
+	}{
+		{
+			name:     "公平锁-自旋超时后离开队列",
+			inputKey: "fair_cancel_timeout",
+			waiter: func(ctx context.Context, lock redislock.RedisLockInter) error {
+				return lock.SpinFairLock(ctx, "waiter_req", 500*time.Millisecond)
+			},
+			wantWaitErr: redislock.ErrSpinLockTimeout,
+		},
+		{
+			name:     "公平锁-上下文取消后离开队列",
+			inputKey: "fair_cancel_ctx",
+			waiter: func(ctx context.Context, lock redislock.RedisLockInter) error {
+				ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+				defer cancel()
+				return lock.SpinFairLock(ctx, "waiter_req", 5*time.Second)
+			},
+			wantWaitErr: redislock.ErrSpinLockDone,
+		},
+		{
+			name:     "公平锁-手动取消排队",
+			inputKey: "fair_cancel_manual",
+			waiter: func(ctx context.Context, lock redislock.RedisLockInter) error {
+				err := lock.FairLock(ctx, "waiter_req")
+				if !errors.Is(err, redislock.ErrLockFailed) {
+					return err
+				}
+				return lock.FairCancel(ctx, "waiter_req")
+			},
+			wantWaitErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			lock := redislock.New(getRedisClient(), tt.inputKey)
+
+			// 持锁者先占用锁
+			require.NoError(t, lock.FairLock(ctx, "holder_req"))
+
+			// 等待者放弃排队
+			err := tt.waiter(ctx, lock)
+			if !errors.Is(err, tt.wantWaitErr) {
+				t.Errorf("Expected error = %v, wantErr %v", err, tt.wantWaitErr)
+			}
+
+			// 持锁者释放后，后来的请求应能立即获取锁，而不是被放弃者阻塞
+			require.NoError(t, lock.FairUnLock(ctx, "holder_req"))
+			require.NoError(t, lock.FairLock(ctx, "next_req"))
+			require.NoError(t, lock.FairUnLock(ctx, "next_req"))
+		})
+	}
+}
+
+func Test_FairCancelHolder(t *testing.T) {
+	ctx := context.Background()
+	lock := redislock.New(getRedisClient(), "fair_cancel_holder")
+
+	require.NoError(t, lock.FairLock(ctx, "holder_req"))
+	defer lock.FairUnLock(ctx, "holder_req")
+
+	// 持锁者不能通过取消排队释放锁
+	err := lock.FairCancel(ctx, "holder_req")
+	require.ErrorIs(t, err, redislock.ErrFairCancelFailed)
+}
+
+func Test_NewFair(t *testing.T) {
+	ctx := context.Background()
+	key := "fair_handle_key"
+
+	first := redislock.NewFair(getRedisClient(), key)
+	second := redislock.NewFair(getRedisClient(), key)
+
+	// 句柄自动生成唯一 requestId
+	require.NotEmpty(t, first.RequestId())
+	require.NotEqual(t, first.RequestId(), second.RequestId())
+
+	require.NoError(t, first.Lock(ctx))
+	require.ErrorIs(t, second.Lock(ctx), redislock.ErrLockFailed)
+	require.NoError(t, first.Renew(ctx))
+
+	// 持锁者释放后，排队的句柄可获取锁
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		_ = first.UnLock(ctx)
+	}()
+	require.NoError(t, second.SpinLock(ctx, 3*time.Second))
+	require.NoError(t, second.UnLock(ctx))
+
+	// WithToken 可指定 requestId，用于跨进程交接
+	custom := redislock.NewFair(getRedisClient(), key, redislock.WithToken("custom_req"))
+	require.Equal(t, "custom_req", custom.RequestId())
+}
+
+func Test_FairLockMaxQueueLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		inputKey   string
+		maxLength  int64
+		queued     []string // 预先排队的请求
+		inputReqId string
+		spin       bool
+		wantErr    error
+	}{
+		{
+			name:       "公平锁-队列未满-排队等待",
+			inputKey:   "fair_queue_not_full",
+			maxLength:  3,
+			queued:     []string{"req_1"},
+			inputReqId: "req_2",
+			wantErr:    redislock.ErrLockFailed,
+		},
+		{
+			name:       "公平锁-队列已满-拒绝入队",
+			inputKey:   "fair_queue_full",
+			maxLength:  2,
+			queued:     []string{"req_1", "req_2"},
+			inputReqId: "req_3",
+			wantErr:    redislock.ErrQueueFull,
+		},
+		{
+			name:       "公平锁-队列已满-已排队请求不受影响",
+			inputKey:   "fair_queue_full_queued",
+			maxLength:  2,
+			queued:     []string{"req_1", "req_2"},
+			inputReqId: "req_2",
+			wantErr:    redislock.ErrLockFailed,
+		},
+		{
+			name:       "公平锁-队列已满-自旋快速失败",
+			inputKey:   "fair_queue_full_spin",
+			maxLength:  1,
+			queued:     []string{"req_1"},
+			inputReqId: "req_2",
+			spin:       true,
+			wantErr:    redislock.ErrQueueFull,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			lock := redislock.New(getRedisClient(), tt.inputKey, redislock.WithMaxQueueLength(tt.maxLength))
+
+			for i, reqId := range tt.queued {
+				err := lock.FairLock(ctx, reqId)
+				if i == 0 {
+					require.NoError(t, err)
+				}
+				defer lock.FairUnLock(ctx, reqId)
+			}
+
+			var err error
+			if tt.spin {
+				start := time.Now()
+				err = lock.SpinFairLock(ctx, tt.inputReqId, 3*time.Second)
+				require.Less(t, time.Since(start), time.Second)
+			} else {
+				err = lock.FairLock(ctx, tt.inputReqId)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	redislock "github.com/jefferyjob/go-redislock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PriorityFairLock(t *testing.T) {
+	type waiter struct {
+		reqId    string
+		priority int
+		delay    time.Duration // 入队前等待时间
+	}
+
+	tests := []struct {
+		name     string
+		inputKey string
+		options  []redislock.Option
+		waiters  []waiter
+		wantNext string // 持锁者释放后应获取锁的请求
+	}{
+		{
+			name:     "优先级公平锁-高优先级插队",
+			inputKey: "priority_high_first",
+			waiters: []waiter{
+				{reqId: "low", priority: 0},
+				{reqId: "high", priority: 10},
+			},
+			wantNext: "high",
+		},
+		{
+			name:     "优先级公平锁-同优先级FIFO",
+			inputKey: "priority_fifo",
+			waiters: []waiter{
+				{reqId: "first", priority: 5},
+				{reqId: "second", priority: 5, delay: 10 * time.Millisecond},
+			},
+			wantNext: "first",
+		},
+		{
+			name:     "优先级公平锁-老化后低优先级晋升",
+			inputKey: "priority_aging",
+			options:  []redislock.Option{redislock.WithPriorityAging(200 * time.Millisecond)},
+			waiters: []waiter{
+				{reqId: "low", priority: 0},
+				{reqId: "high", priority: 2, delay: 1100 * time.Millisecond},
+			},
+			wantNext: "low",
+		},
+		{
+			name:     "优先级公平锁-未开启老化严格按优先级",
+			inputKey: "priority_strict",
+			waiters: []waiter{
+				{reqId: "low", priority: 0},
+				{reqId: "high", priority: 1, delay: 1100 * time.Millisecond},
+			},
+			wantNext: "high",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			lock := redislock.New(getRedisClient(), tt.inputKey, tt.options...)
+
+			// 持锁者先占用锁
+			require.NoError(t, lock.PriorityFairLock(ctx, "holder", 0))
+
+			// 等待者依次入队
+			for _, w := range tt.waiters {
+				time.Sleep(w.delay)
+				err := lock.PriorityFairLock(ctx, w.reqId, w.priority)
+				require.ErrorIs(t, err, redislock.ErrLockFailed)
+				defer lock.FairUnLock(ctx, w.reqId)
+			}
+
+			require.NoError(t, lock.FairUnLock(ctx, "holder"))
+
+			// 只有预期的请求能获取锁
+			for _, w := range tt.waiters {
+				err := lock.PriorityFairLock(ctx, w.reqId, w.priority)
+				if w.reqId == tt.wantNext {
+					require.NoError(t, err)
+				} else if !errors.Is(err, redislock.ErrLockFailed) {
+					t.Errorf("Expected error = %v, wantErr %v", err, redislock.ErrLockFailed)
+				}
+			}
+		})
+	}
+}
+
+func Test_SpinPriorityFairLock(t *testing.T) {
+	ctx := context.Background()
+	lock := redislock.New(getRedisClient(), "priority_spin")
+
+	require.ErrorIs(t, lock.PriorityFairLock(ctx, "req", redislock.MaxPriority+1), redislock.ErrInvalidPriority)
+
+	require.NoError(t, lock.PriorityFairLock(ctx, "holder", 0))
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		_ = lock.FairUnLock(ctx, "holder")
+	}()
+
+	require.NoError(t, lock.SpinPriorityFairLock(ctx, "waiter", 1, 3*time.Second))
+	require.NoError(t, lock.FairRenew(ctx, "waiter"))
+	require.NoError(t, lock.FairUnLock(ctx, "waiter"))
+}
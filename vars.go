@@ -10,6 +10,33 @@ const (
 	lockTime = 5 * time.Second
 	// 默认请求超时时间
 	requestTimeout = lockTime
+	// 公平锁放弃排队时清理队列的超时时间
+	fairCancelTimeout = time.Second
+	// 副本确认不足时回滚加锁的超时时间
+	rollbackTimeout = time.Second
+	// 自动续期遇到故障切换错误时的重试间隔
+	failoverRetryInterval = 200 * time.Millisecond
+	// 解锁、续期遇到临时错误时的默认重试次数
+	retryAttempts = 2
+	// 解锁、续期遇到临时错误时的默认初始退避时间
+	retryBackoff = 50 * time.Millisecond
+	// 续期调度器单次批量续期的最大锁数量
+	renewBatchSize = 500
+	// 续期调度器合并续期的时间窗口，到期时间在窗口内的锁一并续期
+	renewBatchWindow = 50 * time.Millisecond
+	// Do 等辅助函数释放锁的超时时间
+	releaseTimeout = time.Second
+	// 客户端不支持订阅时等待通知的轮询间隔
+	notifyPollInterval = 100 * time.Millisecond
+	// 解锁记录在重试退避时间之外的保留时间，覆盖每次请求自身的耗时
+	releaseRecordGrace = 30 * time.Second
+)
+
+const (
+	// MinPriority 优先级公平锁的最小优先级
+	MinPriority = -1000
+	// MaxPriority 优先级公平锁的最大优先级
+	MaxPriority = 1000
 )
 
 var (
@@ -21,8 +48,42 @@ var (
 	ErrSpinLockTimeout = errors.New("spin lock timeout")
 	// ErrSpinLockDone 自旋锁加锁超时
 	ErrSpinLockDone = errors.New("spin lock context done")
+	// ErrQueueFull 公平锁排队队列已满
+	ErrQueueFull = errors.New("fair lock queue is full")
+	// ErrInvalidPriority 优先级超出范围
+	ErrInvalidPriority = errors.New("priority out of range")
+	// ErrFairCancelFailed 公平锁取消排队失败（请求当前正持有锁）
+	ErrFairCancelFailed = errors.New("fair cancel failed")
 	// ErrLockRenewFailed 锁续期失败
 	ErrLockRenewFailed = errors.New("lock renew failed")
+	// ErrLockHeld 锁被其他持有者占用
+	ErrLockHeld = errors.New("lock held by others")
+	// ErrNotQueueHead 不是公平锁队首，仍需排队
+	ErrNotQueueHead = errors.New("not head of fair queue")
+	// ErrWrongMode 锁模式不匹配（如读写锁模式冲突）
+	ErrWrongMode = errors.New("lock mode mismatch")
+	// ErrNotOwner 不是锁的持有者
+	ErrNotOwner = errors.New("not lock owner")
+	// ErrLockExpired 锁不存在或已过期
+	ErrLockExpired = errors.New("lock expired")
+	// ErrNotEnoughReplicas 确认写入的副本数不足（WithMinReplicas）
+	ErrNotEnoughReplicas = errors.New("not enough replicas acknowledged")
+	// ErrFailover Redis 故障切换中（READONLY、MOVED/ASK、LOADING、连接被重置等），锁的状态未知
+	ErrFailover = errors.New("redis failover in progress")
+	// ErrLockLost 持有期间锁已丢失（自动续期失败或锁已过期）
+	ErrLockLost = errors.New("lock lost")
+	// ErrSharedCallFailed singleflight 获胜者执行 fn 返回错误，等待者收到的错误满足 errors.Is(err, ErrSharedCallFailed)
+	ErrSharedCallFailed = errors.New("shared call failed")
+	// ErrNoLeader 选主当前没有 leader
+	ErrNoLeader = errors.New("no leader elected")
+	// ErrInvalidCount CountDownLatch 的计数必须大于 0
+	ErrInvalidCount = errors.New("latch count must be positive")
+	// ErrInvalidTTL 有效期以毫秒写入 Redis，必须不小于 1ms
+	ErrInvalidTTL = errors.New("ttl must be at least 1ms")
+	// ErrLatchExpired CountDownLatch 不存在：未设置，或计数未归零时已过期
+	ErrLatchExpired = errors.New("latch expired or not set")
+	// ErrRedisUnavailable Redis 不可用，熔断器已熔断
+	ErrRedisUnavailable = errors.New("redis unavailable: circuit breaker is open")
 	// ErrException 内部异常
 	ErrException = errors.New("go redis lock internal exception")
 )